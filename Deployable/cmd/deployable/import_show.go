@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"deployable/internal/showimport"
+)
+
+// runImportShow converts an Editor export into per-role show logic:
+//
+//	deployable import-show --export show.zip --mapping roles.json --out build
+func runImportShow(args []string) error {
+	flags := flag.NewFlagSet("import-show", flag.ContinueOnError)
+	exportPath := flags.String("export", "", "Editor export package (.zip) or show.json")
+	mappingPath := flags.String("mapping", "", "Role mapping JSON assigning outputs and media to device roles")
+	outDir := flags.String("out", "./import", "Output directory; one subdirectory per role")
+	version := flags.Int("logic-version", 1, "Version number for the generated show logic")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *exportPath == "" || *mappingPath == "" {
+		flags.Usage()
+		return fmt.Errorf("--export and --mapping are required")
+	}
+	export, err := showimport.Load(*exportPath)
+	if err != nil {
+		return err
+	}
+	defer export.Close()
+	mapping, err := showimport.LoadMapping(*mappingPath)
+	if err != nil {
+		return err
+	}
+	results, err := showimport.Convert(export, mapping, *version)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		dir := filepath.Join(*outDir, result.Role)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := writeIndented(filepath.Join(dir, "show_logic.json"), result.ShowLogic); err != nil {
+			return err
+		}
+		if err := writeIndented(filepath.Join(dir, "assets.json"), result.Assets); err != nil {
+			return err
+		}
+		copied := 0
+		for _, asset := range result.Assets {
+			if err := copyAsset(export, asset, filepath.Join(dir, "assets")); err != nil {
+				log.Printf("%s: asset %s not copied: %v", result.Role, asset, err)
+				continue
+			}
+			copied++
+		}
+		for _, warning := range result.Warnings {
+			log.Printf("%s: warning: %s", result.Role, warning)
+		}
+		log.Printf("%s: %d states, %d assets (%d copied) -> %s", result.Role, len(result.ShowLogic.States), len(result.Assets), copied, dir)
+	}
+	return nil
+}
+
+func writeIndented(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func copyAsset(export *showimport.Export, name, dir string) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return fmt.Errorf("unsafe asset path")
+	}
+	src, err := export.OpenAsset(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
@@ -9,13 +9,28 @@ import (
 	"time"
 
 	"deployable/internal/config"
+	"deployable/internal/playback"
 	"deployable/internal/runtime"
 	"deployable/internal/server"
 	"deployable/internal/web"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import-show" {
+		if err := runImportShow(os.Args[2:]); err != nil {
+			log.Fatalf("import-show: %v", err)
+		}
+		return
+	}
 	cfg := config.Load()
+	if cfg.StateSource != "server" && cfg.StateSource != "osc" {
+		log.Fatalf("unknown state source %q (use server or osc)", cfg.StateSource)
+	}
+	switch cfg.VLCRestart {
+	case playback.RestartResume, playback.RestartReload, playback.RestartIdle, playback.RestartOff:
+	default:
+		log.Fatalf("unknown vlc restart mode %q (use resume, restart, idle or off)", cfg.VLCRestart)
+	}
 	rt := runtime.NewRuntime(runtime.Config{
 		DataDir:         cfg.DataDir,
 		AssetsDir:       cfg.AssetsDir,
@@ -24,6 +39,25 @@ func main() {
 		PlaybackBackend: cfg.PlaybackBackend,
 		VLCPath:         cfg.VLCPath,
 		VLCDebug:        cfg.VLCDebug,
+		MPVPath:         cfg.MPVPath,
+		MPVAudioDevices: cfg.MPVAudioDevices,
+		TimelineFile:     cfg.TimelineFile,
+		TimelineDuration: time.Duration(cfg.TimelineDurationMs) * time.Millisecond,
+		SyncInterval:     time.Duration(cfg.SyncIntervalMs) * time.Millisecond,
+		SyncTolerance:    time.Duration(cfg.SyncToleranceMs) * time.Millisecond,
+		SyncLead:         time.Duration(cfg.SyncLeadMs) * time.Millisecond,
+		VLCRestart: playback.RestartPolicy{
+			Mode:        cfg.VLCRestart,
+			MaxRestarts: cfg.VLCRestartMax,
+			Window:      time.Duration(cfg.VLCRestartWindowMs) * time.Millisecond,
+			Backoff:     time.Duration(cfg.VLCRestartBackoffMs) * time.Millisecond,
+		},
+		AllowedCommands: cfg.AllowedCommands,
+		AllowedCommandEnv:  cfg.AllowedCommandEnv,
+		CommandWorkDirRoot: cfg.CommandWorkDirRoot,
+		PluginsDir:      cfg.PluginsDir,
+		Journal:         cfg.Journal,
+		ReplayMode:      cfg.ReplayPath != "",
 	})
 	if err := rt.Boot(); err != nil {
 		log.Fatalf("boot failed: %v", err)
@@ -37,8 +71,19 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if cfg.Offline {
+	if cfg.ReplayPath != "" {
+		go func() {
+			log.Printf("replaying %s at %gx", cfg.ReplayPath, cfg.ReplaySpeed)
+			if err := rt.Replay(ctx, cfg.ReplayPath, cfg.ReplaySpeed); err != nil && ctx.Err() == nil {
+				log.Printf("replay failed: %v", err)
+				return
+			}
+			log.Printf("replay finished")
+		}()
+	} else if cfg.Offline {
 		rt.StartOffline()
+	} else if cfg.StateSource == "osc" {
+		rt.StartOSC(ctx, cfg.OSCListenAddr, time.Duration(cfg.OSCHeartbeatTimeoutMs)*time.Millisecond)
 	} else {
 		client := &server.Client{ServerURL: cfg.ServerURL}
 		connected := make(chan time.Time, 1)
@@ -50,6 +95,24 @@ func main() {
 				rt.SetConnected(ts)
 			}
 		}()
+		if cfg.PlaybackStatusIntervalMs > 0 {
+			go rt.ReportPlaybackStatus(ctx, time.Duration(cfg.PlaybackStatusIntervalMs)*time.Millisecond)
+		}
+		if cfg.ClockSyncIntervalMs > 0 {
+			go rt.SyncClock(ctx, time.Duration(cfg.ClockSyncIntervalMs)*time.Millisecond)
+		}
+	}
+	if cfg.ReplayPath != "" || cfg.Offline || cfg.StateSource == "osc" {
+		// Without a State Server nothing sends the outbox. Sensor events
+		// and action errors are still queued for it, so discard them
+		// rather than let the queue fill and stall their senders.
+		go func() {
+			for range rt.Outgoing() {
+			}
+		}()
+	}
+	if cfg.ReplayPath == "" {
+		// Offline and OSC state changes are queued here too.
 		go func() {
 			for msg := range rt.IncomingChannel() {
 				rt.HandleServerMessage(msg)
@@ -57,7 +120,7 @@ func main() {
 		}()
 	}
 
-	webServer := &web.Server{Runtime: rt}
+	webServer := &web.Server{Runtime: rt, SensorPanel: cfg.SensorPanel}
 	go func() {
 		if err := webServer.Listen(cfg.WebListenAddr); err != nil {
 			log.Printf("web server stopped: %v", err)
@@ -66,6 +129,7 @@ func main() {
 
 	waitForSignal()
 	cancel()
+	rt.Shutdown()
 }
 
 func waitForSignal() {
@@ -4,6 +4,8 @@ go 1.22
 
 require (
 	github.com/adrg/libvlc-go/v3 v3.0.6
+	github.com/fsnotify/fsnotify v1.8.0
 	nhooyr.io/websocket v1.8.17
 )
 
+require golang.org/x/sys v0.13.0 // indirect
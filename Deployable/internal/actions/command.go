@@ -0,0 +1,286 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+)
+
+const (
+	defaultCommandTimeout = 10 * time.Second
+	maxCommandOutput      = 64 << 10
+)
+
+// deniedEnv lists variables that make a program load or run other code.
+// A profile can never set them, even if the local allow-list names them.
+var deniedEnv = map[string]bool{
+	"PATH": true, "IFS": true, "ENV": true, "BASH_ENV": true, "SHELLOPTS": true,
+	"PYTHONPATH": true, "PYTHONHOME": true, "PYTHONSTARTUP": true,
+	"PERL5LIB": true, "PERL5OPT": true, "RUBYLIB": true, "RUBYOPT": true,
+	"NODE_PATH": true, "NODE_OPTIONS": true,
+	"CLASSPATH": true, "JAVA_TOOL_OPTIONS": true, "_JAVA_OPTIONS": true,
+	"GCONV_PATH": true, "PATHEXT": true, "COMSPEC": true,
+}
+
+// CommandRegistry holds what this machine allows (from local configuration
+// only): programs, environment variable names and a root for working
+// directories. The current profile declares commands on top of them, and
+// show logic can only refer to declared commands by ID.
+type CommandRegistry struct {
+	mu          sync.Mutex
+	allowed     map[string]bool
+	allowedEnv  map[string]bool
+	workDirRoot string
+	commands    map[string]types.CommandDeclaration
+}
+
+func NewCommandRegistry(allowedPrograms, allowedEnv []string, workDirRoot string) *CommandRegistry {
+	allowed := make(map[string]bool, len(allowedPrograms))
+	for _, program := range allowedPrograms {
+		program = strings.TrimSpace(program)
+		if program == "" {
+			continue
+		}
+		allowed[programKey(program)] = true
+	}
+	env := make(map[string]bool, len(allowedEnv))
+	for _, key := range allowedEnv {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		env[envKey(key)] = true
+	}
+	if workDirRoot = strings.TrimSpace(workDirRoot); workDirRoot != "" {
+		workDirRoot = filepath.Clean(workDirRoot)
+	}
+	return &CommandRegistry{
+		allowed:     allowed,
+		allowedEnv:  env,
+		workDirRoot: workDirRoot,
+		commands:    make(map[string]types.CommandDeclaration),
+	}
+}
+
+func (r *CommandRegistry) Validate(commands []types.CommandDeclaration) error {
+	seen := map[string]bool{}
+	for _, cmd := range commands {
+		if cmd.CommandID == "" {
+			return errors.New("command missing command_id")
+		}
+		if seen[cmd.CommandID] {
+			return errors.New("duplicate command " + cmd.CommandID)
+		}
+		seen[cmd.CommandID] = true
+		if !filepath.IsAbs(cmd.Program) {
+			return fmt.Errorf("command %s program must be an absolute path", cmd.CommandID)
+		}
+		if err := r.check(cmd); err != nil {
+			return err
+		}
+		for i, arg := range cmd.Args {
+			if _, err := parseTemplate(fmt.Sprintf("%s arg %d", cmd.CommandID, i), arg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *CommandRegistry) Configure(commands []types.CommandDeclaration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = make(map[string]types.CommandDeclaration, len(commands))
+	for _, cmd := range commands {
+		r.commands[cmd.CommandID] = cmd
+	}
+}
+
+func (r *CommandRegistry) lookup(id string) (types.CommandDeclaration, error) {
+	r.mu.Lock()
+	cmd, ok := r.commands[id]
+	r.mu.Unlock()
+	if !ok {
+		return cmd, fmt.Errorf("command not declared in profile: %s", id)
+	}
+	return cmd, r.check(cmd)
+}
+
+// check holds a declared command to the local allow-lists. The program,
+// its environment and its working directory all come from the server, and
+// each could otherwise be used to run code of the server's choosing, for
+// example LD_PRELOAD pointing at a synced asset.
+func (r *CommandRegistry) check(cmd types.CommandDeclaration) error {
+	if !r.allowed[programKey(cmd.Program)] {
+		return fmt.Errorf("command %s program not in local allow-list: %s", cmd.CommandID, cmd.Program)
+	}
+	for key := range cmd.Env {
+		upper := strings.ToUpper(key)
+		if deniedEnv[upper] || strings.HasPrefix(upper, "LD_") || strings.HasPrefix(upper, "DYLD_") {
+			return fmt.Errorf("command %s may not set %s", cmd.CommandID, key)
+		}
+		if !r.allowedEnv[envKey(key)] {
+			return fmt.Errorf("command %s env %s not in local allow-list", cmd.CommandID, key)
+		}
+	}
+	if cmd.WorkDir != "" {
+		if r.workDirRoot == "" {
+			return fmt.Errorf("command %s sets work_dir but no local work dir root is configured", cmd.CommandID)
+		}
+		if !filepath.IsAbs(cmd.WorkDir) || !withinDir(r.workDirRoot, cmd.WorkDir) {
+			return fmt.Errorf("command %s work_dir must be inside %s: %s", cmd.CommandID, r.workDirRoot, cmd.WorkDir)
+		}
+	}
+	return nil
+}
+
+type CommandExecutor struct {
+	Commands *CommandRegistry
+	Emit     func(types.SensorEvent)
+}
+
+func (e CommandExecutor) ActionName() string {
+	return "command.run"
+}
+
+func (e CommandExecutor) Blocking() bool {
+	return true
+}
+
+func (e CommandExecutor) Execute(target string, params map[string]any) error {
+	id := stringParam(params, "command")
+	if id == "" {
+		id = target
+	}
+	if id == "" {
+		return errors.New("command.run requires params.command")
+	}
+	decl, err := e.Commands.lookup(id)
+	if err != nil {
+		return err
+	}
+	args := make([]string, 0, len(decl.Args))
+	for i, tmpl := range decl.Args {
+		arg, err := renderTemplate(fmt.Sprintf("%s arg %d", id, i), tmpl, params)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+	timeout := defaultCommandTimeout
+	if decl.TimeoutMs > 0 {
+		timeout = time.Duration(decl.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, decl.Program, args...)
+	cmd.Env = commandEnv(decl.Env)
+	cmd.Dir = decl.WorkDir
+	stdout := &limitedBuffer{limit: maxCommandOutput}
+	stderr := &limitedBuffer{limit: maxCommandOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	started := time.Now()
+	runErr := cmd.Run()
+	result := map[string]any{
+		"command_id":  id,
+		"exit_code":   -1,
+		"stdout":      stdout.String(),
+		"stderr":      stderr.String(),
+		"duration_ms": time.Since(started).Milliseconds(),
+		"timed_out":   errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	if cmd.ProcessState != nil {
+		result["exit_code"] = cmd.ProcessState.ExitCode()
+	}
+	eventType := "command_result"
+	if runErr != nil {
+		eventType = "command_error"
+		result["error"] = runErr.Error()
+	}
+	if sensorID := stringParam(params, "event_sensor_id"); sensorID != "" && e.Emit != nil {
+		e.Emit(types.SensorEvent{
+			SensorID:   sensorID,
+			SensorType: "command",
+			EventType:  eventType,
+			Value:      result,
+			Timestamp:  time.Now().UTC(),
+		})
+	}
+	if runErr != nil {
+		return fmt.Errorf("command %s failed: %w", id, runErr)
+	}
+	return nil
+}
+
+// commandEnv starts from an empty environment so only declared variables
+// reach the program. Windows programs additionally need SystemRoot to load.
+func commandEnv(declared map[string]string) []string {
+	env := []string{}
+	if runtime.GOOS == "windows" {
+		for _, key := range []string{"SystemRoot", "WINDIR"} {
+			if value := os.Getenv(key); value != "" {
+				env = append(env, key+"="+value)
+			}
+		}
+	}
+	for key, value := range declared {
+		env = append(env, key+"="+value)
+	}
+	return env
+}
+
+func envKey(key string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+// withinDir reports whether path is root or below it.
+func withinDir(root, path string) bool {
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func programKey(program string) string {
+	clean := filepath.Clean(program)
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(clean)
+	}
+	return clean
+}
+
+type limitedBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) > room {
+			b.buf = append(b.buf, p[:room]...)
+		} else {
+			b.buf = append(b.buf, p...)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
+
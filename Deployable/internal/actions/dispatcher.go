@@ -1,7 +1,9 @@
 package actions
 
 import (
+	"errors"
 	"log"
+	"sync"
 
 	"deployable/internal/types"
 )
@@ -11,7 +13,14 @@ type ActionExecutor interface {
 	Execute(target string, params map[string]any) error
 }
 
+// BlockingExecutor marks executors that wait on network or process I/O.
+// The dispatcher runs them off its loop so media cues are not held up.
+type BlockingExecutor interface {
+	Blocking() bool
+}
+
 type Dispatcher struct {
+	mu        sync.RWMutex
 	executors map[string]ActionExecutor
 	incoming  <-chan types.EngineAction
 	errorSink chan<- DispatchError
@@ -40,22 +49,56 @@ func (d *Dispatcher) Run(stop <-chan struct{}) {
 		case <-stop:
 			return
 		case action := <-d.incoming:
+			d.mu.RLock()
 			exec, ok := d.executors[action.Action]
+			d.mu.RUnlock()
 			if !ok {
 				log.Printf("action executor not found: %s", action.Action)
 				continue
 			}
-			if err := exec.Execute(action.Target, action.Params); err != nil {
-				log.Printf("action %s failed: %v", action.Action, err)
-				if d.errorSink != nil {
-					d.errorSink <- DispatchError{Action: action, Err: err}
-				}
+			if blocking, ok := exec.(BlockingExecutor); ok && blocking.Blocking() {
+				go d.execute(exec, action)
+				continue
 			}
+			d.execute(exec, action)
+		}
+	}
+}
+
+func (d *Dispatcher) execute(exec ActionExecutor, action types.EngineAction) {
+	if err := exec.Execute(action.Target, action.Params); err != nil {
+		log.Printf("action %s failed: %v", action.Action, err)
+		if d.errorSink != nil {
+			d.errorSink <- DispatchError{Action: action, Err: err}
 		}
 	}
 }
 
+// Register adds an executor after construction, e.g. one provided by a
+// plugin. Built-in action names cannot be replaced.
+func (d *Dispatcher) Register(exec ActionExecutor) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := exec.ActionName()
+	if name == "" {
+		return errors.New("executor missing action name")
+	}
+	if _, exists := d.executors[name]; exists {
+		return errors.New("action already registered: " + name)
+	}
+	d.executors[name] = exec
+	return nil
+}
+
+func (d *Dispatcher) Unregister(name string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.executors, name)
+}
+
 func (d *Dispatcher) SupportedActions() map[string]bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	supported := make(map[string]bool, len(d.executors))
 	for name := range d.executors {
 		supported[name] = true
@@ -4,12 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"deployable/internal/playback"
 )
 
+// Clock converts State Server timestamps to local time.
+type Clock interface {
+	ToLocal(serverTime time.Time) time.Time
+}
+
 type PlayVideoExecutor struct {
 	Player playback.PlaybackService
+	Clock  Clock
 }
 
 func (e PlayVideoExecutor) ActionName() string {
@@ -21,6 +28,9 @@ func (e PlayVideoExecutor) Execute(target string, params map[string]any) error {
 	if err != nil {
 		return err
 	}
+	if req.StartAt, err = startAt(params, e.Clock); err != nil {
+		return err
+	}
 	return e.Player.Play(target, req)
 }
 
@@ -33,11 +43,12 @@ func (e StopVideoExecutor) ActionName() string {
 }
 
 func (e StopVideoExecutor) Execute(target string, params map[string]any) error {
-	return e.Player.Stop(target)
+	return stopOutput(e.Player, target, params)
 }
 
 type PlayAudioExecutor struct {
 	Player playback.PlaybackService
+	Clock  Clock
 }
 
 func (e PlayAudioExecutor) ActionName() string {
@@ -49,9 +60,46 @@ func (e PlayAudioExecutor) Execute(target string, params map[string]any) error {
 	if err != nil {
 		return err
 	}
+	if req.StartAt, err = startAt(params, e.Clock); err != nil {
+		return err
+	}
 	return e.Player.Play(target, req)
 }
 
+// PrepareVideoExecutor cues a video so a later play_video of the same file
+// on the same output starts immediately.
+type PrepareVideoExecutor struct {
+	Player playback.PlaybackService
+}
+
+func (e PrepareVideoExecutor) ActionName() string {
+	return "prepare_video"
+}
+
+func (e PrepareVideoExecutor) Execute(target string, params map[string]any) error {
+	req, err := playRequestFromParams(params, false)
+	if err != nil {
+		return err
+	}
+	return e.Player.Prepare(target, req)
+}
+
+type PrepareAudioExecutor struct {
+	Player playback.PlaybackService
+}
+
+func (e PrepareAudioExecutor) ActionName() string {
+	return "prepare_audio"
+}
+
+func (e PrepareAudioExecutor) Execute(target string, params map[string]any) error {
+	req, err := playRequestFromParams(params, true)
+	if err != nil {
+		return err
+	}
+	return e.Player.Prepare(target, req)
+}
+
 type StopAudioExecutor struct {
 	Player playback.PlaybackService
 }
@@ -61,7 +109,7 @@ func (e StopAudioExecutor) ActionName() string {
 }
 
 func (e StopAudioExecutor) Execute(target string, params map[string]any) error {
-	return e.Player.Stop(target)
+	return stopOutput(e.Player, target, params)
 }
 
 type SetVolumeExecutor struct {
@@ -89,8 +137,17 @@ func (e StopAllExecutor) ActionName() string {
 }
 
 func (e StopAllExecutor) Execute(target string, params map[string]any) error {
+	curve, err := curveParam(params)
+	if err != nil {
+		return err
+	}
+	fadeOutMs := intParam(params, "fade_out_ms")
 	for _, output := range e.Player.ListOutputs() {
-		_ = e.Player.Stop(output.ID)
+		if fadeOutMs > 0 {
+			_ = e.Player.FadeOut(output.ID, fadeOutMs, curve)
+		} else {
+			_ = e.Player.Stop(output.ID)
+		}
 	}
 	return nil
 }
@@ -132,8 +189,12 @@ func (e FadeVolumeExecutor) Execute(target string, params map[string]any) error
 	if err != nil {
 		return err
 	}
+	curve, err := curveParam(params)
+	if err != nil {
+		return err
+	}
 	durationMs := intParam(params, "duration_ms")
-	return e.Player.FadeVolume(target, targetVol, durationMs)
+	return e.Player.FadeVolume(target, targetVol, durationMs, curve)
 }
 
 type SeekExecutor struct {
@@ -151,6 +212,7 @@ func (e SeekExecutor) Execute(target string, params map[string]any) error {
 
 type MediaPlayExecutor struct {
 	Player playback.PlaybackService
+	Clock  Clock
 }
 
 func (e MediaPlayExecutor) ActionName() string {
@@ -162,9 +224,91 @@ func (e MediaPlayExecutor) Execute(target string, params map[string]any) error {
 	if err != nil {
 		return err
 	}
+	if req.StartAt, err = startAt(params, e.Clock); err != nil {
+		return err
+	}
 	return e.Player.Play(target, req)
 }
 
+// PlaySyncExecutor starts several outputs together. params.items lists
+// play actions as {action, target, params}; the group is named by
+// params.group or the action's target. An effective_at on the action
+// applies to items without their own.
+type PlaySyncExecutor struct {
+	Player playback.PlaybackService
+	Clock  Clock
+}
+
+func (e PlaySyncExecutor) ActionName() string {
+	return "play_sync"
+}
+
+func (e PlaySyncExecutor) Execute(target string, params map[string]any) error {
+	group := stringParam(params, "group")
+	if group == "" {
+		group = target
+	}
+	if group == "" {
+		return errors.New("play_sync requires a target or params.group")
+	}
+	rawItems, ok := params["items"].([]any)
+	if !ok || len(rawItems) == 0 {
+		return errors.New("play_sync requires params.items")
+	}
+	items := make([]playback.SyncItem, 0, len(rawItems))
+	for _, raw := range rawItems {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			return errors.New("play_sync items must be objects")
+		}
+		outputID := stringParam(item, "target")
+		if outputID == "" {
+			return errors.New("play_sync item requires target")
+		}
+		itemParams, _ := item["params"].(map[string]any)
+		var req playback.PlayRequest
+		var err error
+		switch action := stringParam(item, "action"); action {
+		case "", "play_video":
+			req, err = playRequestFromParams(itemParams, false)
+		case "play_audio":
+			req, err = playRequestFromParams(itemParams, true)
+		case "media.play":
+			req, err = playRequestFromMediaParams(itemParams)
+		default:
+			err = fmt.Errorf("play_sync cannot run %s", action)
+		}
+		if err != nil {
+			return err
+		}
+		timing := itemParams
+		if _, ok := itemParams["effective_at"]; !ok {
+			timing = params
+		}
+		if req.StartAt, err = startAt(timing, e.Clock); err != nil {
+			return err
+		}
+		items = append(items, playback.SyncItem{OutputID: outputID, Request: req})
+	}
+	return e.Player.PlaySync(group, items)
+}
+
+type MediaPrepareExecutor struct {
+	Player playback.PlaybackService
+}
+
+func (e MediaPrepareExecutor) ActionName() string {
+	return "media.prepare"
+}
+
+func (e MediaPrepareExecutor) Execute(target string, params map[string]any) error {
+	req, err := playRequestFromMediaParams(params)
+	if err != nil {
+		return err
+	}
+	return e.Player.Prepare(target, req)
+}
+
 type MediaStopExecutor struct {
 	Player playback.PlaybackService
 }
@@ -174,7 +318,7 @@ func (e MediaStopExecutor) ActionName() string {
 }
 
 func (e MediaStopExecutor) Execute(target string, params map[string]any) error {
-	return e.Player.Stop(target)
+	return stopOutput(e.Player, target, params)
 }
 
 type MediaPauseExecutor struct {
@@ -246,8 +390,12 @@ func (e MediaFadeExecutor) Execute(target string, params map[string]any) error {
 	if err != nil {
 		return err
 	}
+	curve, err := curveParam(params)
+	if err != nil {
+		return err
+	}
 	durationMs := intParam(params, "duration_ms")
-	return e.Player.FadeVolume(target, targetVol, durationMs)
+	return e.Player.FadeVolume(target, targetVol, durationMs, curve)
 }
 
 func playRequestFromParams(params map[string]any, allowVolume bool) (playback.PlayRequest, error) {
@@ -256,11 +404,17 @@ func playRequestFromParams(params map[string]any, allowVolume bool) (playback.Pl
 		return playback.PlayRequest{}, errors.New("play requires params.file")
 	}
 	req := playback.PlayRequest{
-		AssetPath: file,
-		Loop:      boolParam(params, "loop"),
-		StartMs:   intParam(params, "start_ms"),
-		FadeInMs:  intParam(params, "fade_in_ms"),
+		AssetPath:   file,
+		Loop:        boolParam(params, "loop"),
+		StartMs:     intParam(params, "start_ms"),
+		FadeInMs:    intParam(params, "fade_in_ms"),
+		CrossfadeMs: intParam(params, "crossfade_ms"),
+	}
+	curve, err := curveParam(params)
+	if err != nil {
+		return playback.PlayRequest{}, err
 	}
+	req.FadeCurve = curve
 	if allowVolume {
 		if volume, err := optionalFloatParam(params, "volume"); err != nil {
 			return playback.PlayRequest{}, err
@@ -277,11 +431,17 @@ func playRequestFromMediaParams(params map[string]any) (playback.PlayRequest, er
 		return playback.PlayRequest{}, errors.New("media.play requires params.asset")
 	}
 	req := playback.PlayRequest{
-		AssetPath: asset,
-		Loop:      boolParam(params, "loop"),
-		StartMs:   intParam(params, "start_ms"),
-		FadeInMs:  intParam(params, "fade_in_ms"),
+		AssetPath:   asset,
+		Loop:        boolParam(params, "loop"),
+		StartMs:     intParam(params, "start_ms"),
+		FadeInMs:    intParam(params, "fade_in_ms"),
+		CrossfadeMs: intParam(params, "crossfade_ms"),
+	}
+	curve, err := curveParam(params)
+	if err != nil {
+		return playback.PlayRequest{}, err
 	}
+	req.FadeCurve = curve
 	if volume, err := optionalFloatParam(params, "volume"); err != nil {
 		return playback.PlayRequest{}, err
 	} else if volume != nil {
@@ -290,6 +450,48 @@ func playRequestFromMediaParams(params map[string]any) (playback.PlayRequest, er
 	return req, nil
 }
 
+// stopOutput stops target, after fading it out when params.fade_out_ms is
+// set.
+func stopOutput(player playback.PlaybackService, target string, params map[string]any) error {
+	fadeOutMs := intParam(params, "fade_out_ms")
+	if fadeOutMs <= 0 {
+		return player.Stop(target)
+	}
+	curve, err := curveParam(params)
+	if err != nil {
+		return err
+	}
+	return player.FadeOut(target, fadeOutMs, curve)
+}
+
+// startAt reads params.effective_at, a State Server time, as a local
+// time. It is zero when the param is absent.
+func startAt(params map[string]any, clock Clock) (time.Time, error) {
+	var at time.Time
+	switch value := params["effective_at"].(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		at = value
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid effective_at: %w", err)
+		}
+		at = parsed
+	default:
+		return time.Time{}, fmt.Errorf("invalid effective_at: %v", value)
+	}
+	if clock != nil {
+		at = clock.ToLocal(at)
+	}
+	return at, nil
+}
+
+func curveParam(params map[string]any) (playback.FadeCurve, error) {
+	return playback.ParseFadeCurve(stringParam(params, "curve"))
+}
+
 func boolParam(params map[string]any, key string) bool {
 	if raw, ok := params[key]; ok {
 		if value, ok := raw.(bool); ok {
@@ -299,6 +501,15 @@ func boolParam(params map[string]any, key string) bool {
 	return false
 }
 
+func stringParam(params map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := params[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func intParam(params map[string]any, keys ...string) int {
 	for _, key := range keys {
 		if raw, ok := params[key]; ok {
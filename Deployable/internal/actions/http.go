@@ -0,0 +1,191 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"deployable/internal/types"
+)
+
+const (
+	defaultHTTPTimeout  = 5 * time.Second
+	maxHTTPResponseBody = 1 << 20
+)
+
+type HTTPRequestExecutor struct {
+	Client *http.Client
+	// Emit receives the response events, as sensor input.
+	Emit func(types.SensorEvent)
+}
+
+func (e HTTPRequestExecutor) ActionName() string {
+	return "http.request"
+}
+
+func (e HTTPRequestExecutor) Blocking() bool {
+	return true
+}
+
+func (e HTTPRequestExecutor) Execute(target string, params map[string]any) error {
+	req, err := buildHTTPRequest(target, params)
+	if err != nil {
+		return err
+	}
+	timeout := defaultHTTPTimeout
+	if ms := intParam(params, "timeout_ms"); ms > 0 {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	eventSensor := stringParam(params, "event_sensor_id")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		e.emit(eventSensor, "http_error", map[string]any{
+			"url":   req.URL.Redacted(),
+			"error": err.Error(),
+		})
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBody))
+	if err != nil {
+		e.emit(eventSensor, "http_error", map[string]any{
+			"url":    req.URL.Redacted(),
+			"status": resp.StatusCode,
+			"error":  err.Error(),
+		})
+		return err
+	}
+	if !statusExpected(resp.StatusCode, params["expect_status"]) {
+		err := fmt.Errorf("http.request %s %s: unexpected status %s", req.Method, req.URL.Redacted(), resp.Status)
+		e.emit(eventSensor, "http_error", map[string]any{
+			"url":    req.URL.Redacted(),
+			"status": resp.StatusCode,
+			"body":   decodeResponseBody(resp.Header.Get("Content-Type"), body),
+			"error":  err.Error(),
+		})
+		return err
+	}
+	e.emit(eventSensor, "http_response", map[string]any{
+		"url":    req.URL.Redacted(),
+		"status": resp.StatusCode,
+		"body":   decodeResponseBody(resp.Header.Get("Content-Type"), body),
+	})
+	return nil
+}
+
+func (e HTTPRequestExecutor) emit(sensorID, eventType string, value map[string]any) {
+	if sensorID == "" || e.Emit == nil {
+		return
+	}
+	e.Emit(types.SensorEvent{
+		SensorID:   sensorID,
+		SensorType: "http",
+		EventType:  eventType,
+		Value:      value,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+func buildHTTPRequest(target string, params map[string]any) (*http.Request, error) {
+	rawURL := stringParam(params, "url")
+	if rawURL == "" {
+		rawURL = target
+	}
+	if rawURL == "" {
+		return nil, errors.New("http.request requires params.url")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("http.request invalid url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("http.request unsupported url scheme: %s", parsed.Scheme)
+	}
+
+	var body io.Reader
+	contentType := ""
+	if text, ok := params["body_template"].(string); ok && text != "" {
+		rendered, err := renderTemplate("body_template", text, params)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(rendered)
+	} else if raw, ok := params["body"]; ok && raw != nil {
+		if text, ok := raw.(string); ok {
+			body = strings.NewReader(text)
+		} else {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				return nil, fmt.Errorf("http.request body encode failed: %w", err)
+			}
+			body = bytes.NewReader(data)
+			contentType = "application/json"
+		}
+	}
+
+	method := strings.ToUpper(stringParam(params, "method"))
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+	req, err := http.NewRequest(method, parsed.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if headers, ok := params["headers"].(map[string]any); ok {
+		for key, value := range headers {
+			req.Header.Set(key, fmt.Sprint(value))
+		}
+	}
+	return req, nil
+}
+
+func statusExpected(status int, expected any) bool {
+	switch value := expected.(type) {
+	case nil:
+		return status >= 200 && status < 300
+	case []any:
+		for _, item := range value {
+			if code, err := toFloat(item); err == nil && int(code) == status {
+				return true
+			}
+		}
+		return false
+	default:
+		code, err := toFloat(value)
+		return err == nil && int(code) == status
+	}
+}
+
+func decodeResponseBody(contentType string, body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if strings.Contains(contentType, "json") {
+		var decoded any
+		if err := json.Unmarshal(body, &decoded); err == nil {
+			return decoded
+		}
+	}
+	return string(body)
+}
+
@@ -0,0 +1,64 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"deployable/internal/mqtt"
+)
+
+type MQTTPublishExecutor struct {
+	Broker mqtt.Publisher
+}
+
+func (e MQTTPublishExecutor) ActionName() string {
+	return "mqtt.publish"
+}
+
+func (e MQTTPublishExecutor) Blocking() bool {
+	return true
+}
+
+func (e MQTTPublishExecutor) Execute(target string, params map[string]any) error {
+	topic := stringParam(params, "topic")
+	if topic == "" {
+		return errors.New("mqtt.publish requires params.topic")
+	}
+	brokerID := stringParam(params, "broker")
+	if brokerID == "" {
+		brokerID = target
+	}
+	payload, err := mqttPayload(params)
+	if err != nil {
+		return err
+	}
+	qos := intParam(params, "qos")
+	if qos < 0 || qos > 2 {
+		return fmt.Errorf("mqtt.publish invalid qos: %d", qos)
+	}
+	return e.Broker.Publish(brokerID, topic, payload, byte(qos), boolParam(params, "retain"))
+}
+
+func mqttPayload(params map[string]any) ([]byte, error) {
+	if text, ok := params["payload_template"].(string); ok && text != "" {
+		rendered, err := renderTemplate("payload_template", text, params)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(rendered), nil
+	}
+	switch value := params["payload"].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(value), nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt.publish payload encode failed: %w", err)
+		}
+		return data, nil
+	}
+}
+
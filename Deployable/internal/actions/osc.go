@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"deployable/internal/osc"
+)
+
+type OSCSendExecutor struct{}
+
+func (e OSCSendExecutor) ActionName() string {
+	return "osc.send"
+}
+
+func (e OSCSendExecutor) Blocking() bool {
+	return true
+}
+
+// Execute sends one OSC message over UDP to the host:port in target.
+// params.args may be a list or a single value; params.value is accepted
+// as a shorthand for a single argument.
+func (e OSCSendExecutor) Execute(target string, params map[string]any) error {
+	host := stringParam(params, "host")
+	if host == "" {
+		host = target
+	}
+	if host == "" {
+		return errors.New("osc.send requires a host:port target")
+	}
+	address := stringParam(params, "address")
+	if address == "" {
+		return errors.New("osc.send requires params.address")
+	}
+	var args []any
+	switch raw := params["args"].(type) {
+	case nil:
+		if value, ok := params["value"]; ok {
+			args = []any{value}
+		}
+	case []any:
+		args = raw
+	default:
+		args = []any{raw}
+	}
+	packet, err := osc.EncodeMessage(address, args...)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("udp", host, time.Second)
+	if err != nil {
+		return fmt.Errorf("osc.send %s: %w", host, err)
+	}
+	defer conn.Close()
+	_, err = conn.Write(packet)
+	return err
+}
+
@@ -0,0 +1,20 @@
+package actions
+
+import "log"
+
+// ReplayExecutor stands in for an action that reaches outside the device.
+// During replay it only logs, so a journal can be replayed next to a live
+// installation without driving its lights, relays or web hooks.
+type ReplayExecutor struct {
+	Name string
+}
+
+func (e ReplayExecutor) ActionName() string {
+	return e.Name
+}
+
+func (e ReplayExecutor) Execute(target string, params map[string]any) error {
+	log.Printf("replay: skipped %s %s", e.Name, target)
+	return nil
+}
+
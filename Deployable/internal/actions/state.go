@@ -0,0 +1,33 @@
+package actions
+
+import "errors"
+
+// StateRequester forwards a device's request to change the global state to
+// whoever owns it, normally the State Server.
+type StateRequester interface {
+	RequestState(state, reason, eventSensorID string) (string, error)
+}
+
+type StateRequestExecutor struct {
+	Requests StateRequester
+}
+
+func (e StateRequestExecutor) ActionName() string {
+	return "state.request"
+}
+
+func (e StateRequestExecutor) Execute(target string, params map[string]any) error {
+	state := stringParam(params, "state")
+	if state == "" {
+		state = target
+	}
+	if state == "" {
+		return errors.New("state.request requires params.state")
+	}
+	if e.Requests == nil {
+		return errors.New("state.request has no requester")
+	}
+	_, err := e.Requests.RequestState(state, stringParam(params, "reason"), stringParam(params, "event_sensor_id"))
+	return err
+}
+
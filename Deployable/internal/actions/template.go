@@ -0,0 +1,45 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s template invalid: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate expands text/template syntax against the action params,
+// exposed as .params, plus the current UTC time as .time.
+func renderTemplate(name, text string, params map[string]any) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	data := map[string]any{
+		"params": params,
+		"time":   time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("%s template failed: %w", name, err)
+	}
+	return out.String(), nil
+}
+
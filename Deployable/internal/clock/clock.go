@@ -0,0 +1,126 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// maxSamples is how many recent round trips the offset is chosen from.
+	maxSamples = 16
+	// pingExpiry drops pings whose pong never came.
+	pingExpiry = 5 * time.Second
+)
+
+type sample struct {
+	offset time.Duration
+	rtt    time.Duration
+	at     time.Time
+}
+
+// Estimator tracks how far the State Server's clock is ahead of the local
+// one from ping/pong round trips. Assuming the pong's server time was taken
+// halfway through the round trip, each exchange gives an offset accurate to
+// half its round-trip time; the estimate uses the recent exchange with the
+// shortest round trip, since it was least delayed by the network.
+type Estimator struct {
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]time.Time
+	samples []sample
+}
+
+func NewEstimator() *Estimator {
+	return &Estimator{pending: make(map[int64]time.Time)}
+}
+
+// Ping registers a ping about to be sent and returns its ID and send time.
+func (e *Estimator) Ping() (int64, time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	for id, sent := range e.pending {
+		if now.Sub(sent) > pingExpiry {
+			delete(e.pending, id)
+		}
+	}
+	e.nextID++
+	e.pending[e.nextID] = now
+	return e.nextID, now
+}
+
+// Pong records the server time reported for ping id. It returns false for
+// unknown or expired pings.
+func (e *Estimator) Pong(id int64, serverTime time.Time) bool {
+	received := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sent, ok := e.pending[id]
+	if !ok || serverTime.IsZero() {
+		return false
+	}
+	delete(e.pending, id)
+	rtt := received.Sub(sent)
+	midpoint := sent.Add(rtt / 2)
+	e.samples = append(e.samples, sample{
+		offset: serverTime.Sub(midpoint),
+		rtt:    rtt,
+		at:     received,
+	})
+	if len(e.samples) > maxSamples {
+		e.samples = e.samples[len(e.samples)-maxSamples:]
+	}
+	return true
+}
+
+// Offset is the server clock minus the local clock. ok is false until a
+// round trip has completed.
+func (e *Estimator) Offset() (offset, rtt time.Duration, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	best, ok := e.bestLocked()
+	return best.offset, best.rtt, ok
+}
+
+// ToLocal converts a server time to local time. Without an estimate the
+// clocks are assumed to agree.
+func (e *Estimator) ToLocal(serverTime time.Time) time.Time {
+	offset, _, _ := e.Offset()
+	return serverTime.Add(-offset)
+}
+
+// ServerNow is the current time on the server's clock.
+func (e *Estimator) ServerNow() time.Time {
+	offset, _, _ := e.Offset()
+	return time.Now().Add(offset)
+}
+
+func (e *Estimator) Snapshot() map[string]any {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	best, ok := e.bestLocked()
+	if !ok {
+		return map[string]any{"synced": false}
+	}
+	return map[string]any{
+		"synced":    true,
+		"offset_ms": float64(best.offset) / float64(time.Millisecond),
+		"rtt_ms":    float64(best.rtt) / float64(time.Millisecond),
+		"samples":   len(e.samples),
+		"sample_at": best.at.UTC(),
+	}
+}
+
+func (e *Estimator) bestLocked() (sample, bool) {
+	if len(e.samples) == 0 {
+		return sample{}, false
+	}
+	best := e.samples[0]
+	for _, s := range e.samples[1:] {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	return best, true
+}
+
@@ -3,6 +3,8 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +21,30 @@ type Config struct {
 	VLCPath         string
 	DiagnosticShowLogic bool
 	VLCDebug        bool
+	VLCRestart      string
+	MPVPath         string
+	MPVAudioDevices map[string]string
+	TimelineFile    string
+	TimelineDurationMs int
+	VLCRestartMax   int
+	VLCRestartWindowMs  int
+	VLCRestartBackoffMs int
+	PlaybackStatusIntervalMs int
+	SyncIntervalMs  int
+	SyncToleranceMs int
+	ClockSyncIntervalMs int
+	SyncLeadMs      int
+	AllowedCommands []string
+	AllowedCommandEnv  []string
+	CommandWorkDirRoot string
+	PluginsDir      string
+	Journal         bool
+	ReplayPath      string
+	ReplaySpeed     float64
+	SensorPanel     bool
+	StateSource     string
+	OSCListenAddr   string
+	OSCHeartbeatTimeoutMs int
 }
 
 func Load() Config {
@@ -31,10 +57,34 @@ func Load() Config {
 	flag.StringVar(&cfg.WebListenAddr, "web", envOrDefault("DEPLOYABLE_WEB_ADDR", ":8090"), "Web GUI listen address")
 	flag.StringVar(&cfg.AgentVersion, "version", envOrDefault("DEPLOYABLE_VERSION", "dev"), "Agent version string")
 	flag.BoolVar(&cfg.Offline, "offline", envBool("DEPLOYABLE_OFFLINE", false), "Run without a State Server")
-	flag.StringVar(&cfg.PlaybackBackend, "playback-backend", envOrDefault("DEPLOYABLE_PLAYBACK_BACKEND", "vlc"), "Playback backend: vlc, libvlc, or stub")
+	flag.StringVar(&cfg.PlaybackBackend, "playback-backend", envOrDefault("DEPLOYABLE_PLAYBACK_BACKEND", "vlc"), "Playback backend: vlc, mpv, libvlc, timeline, or stub")
 	flag.StringVar(&cfg.VLCPath, "vlc-path", envOrDefault("DEPLOYABLE_VLC_PATH", "vlc"), "Path to VLC executable (for vlc backend)")
 	flag.BoolVar(&cfg.DiagnosticShowLogic, "diagnostic-showlogic", envBool("DEPLOYABLE_DIAGNOSTIC_SHOWLOGIC", false), "Generate and use diagnostic show logic based on discovered outputs")
 	flag.BoolVar(&cfg.VLCDebug, "vlc-debug", envBool("DEPLOYABLE_VLC_DEBUG", false), "Enable VLC stderr logging for RC debugging")
+	flag.StringVar(&cfg.VLCRestart, "vlc-restart", envOrDefault("DEPLOYABLE_VLC_RESTART", "resume"), "What to do when VLC exits or its RC connection drops: resume, restart, idle, or off")
+	flag.IntVar(&cfg.VLCRestartMax, "vlc-restart-max", envInt("DEPLOYABLE_VLC_RESTART_MAX", 5), "Give up after this many VLC restarts within the restart window (0 for no limit)")
+	flag.IntVar(&cfg.VLCRestartWindowMs, "vlc-restart-window-ms", envInt("DEPLOYABLE_VLC_RESTART_WINDOW_MS", 60000), "Window for counting VLC restarts")
+	flag.IntVar(&cfg.VLCRestartBackoffMs, "vlc-restart-backoff-ms", envInt("DEPLOYABLE_VLC_RESTART_BACKOFF_MS", 1000), "Delay before restarting VLC")
+	flag.StringVar(&cfg.MPVPath, "mpv-path", envOrDefault("DEPLOYABLE_MPV_PATH", "mpv"), "Path to mpv executable (for mpv backend)")
+	mpvAudioDevices := flag.String("mpv-audio-devices", envOrDefault("DEPLOYABLE_MPV_AUDIO_DEVICES", ""), "mpv audio device per output as output=device pairs separated by ';' (for mpv backend)")
+	flag.StringVar(&cfg.TimelineFile, "timeline-file", envOrDefault("DEPLOYABLE_TIMELINE_FILE", ""), "Also write the playback timeline to this JSON lines file (for timeline backend)")
+	flag.IntVar(&cfg.TimelineDurationMs, "timeline-duration-ms", envInt("DEPLOYABLE_TIMELINE_DURATION_MS", 0), "Simulated media duration; 0 means media never ends (for timeline backend)")
+	flag.IntVar(&cfg.PlaybackStatusIntervalMs, "playback-status-interval-ms", envInt("DEPLOYABLE_PLAYBACK_STATUS_INTERVAL_MS", 5000), "How often to send playback status to the State Server (0 disables)")
+	flag.IntVar(&cfg.SyncIntervalMs, "sync-interval-ms", envInt("DEPLOYABLE_SYNC_INTERVAL_MS", 5000), "How often sync groups are checked for drift (0 disables drift correction)")
+	flag.IntVar(&cfg.SyncToleranceMs, "sync-tolerance-ms", envInt("DEPLOYABLE_SYNC_TOLERANCE_MS", 100), "Drift allowed between outputs of a sync group before they are seeked back")
+	flag.IntVar(&cfg.ClockSyncIntervalMs, "clock-sync-interval-ms", envInt("DEPLOYABLE_CLOCK_SYNC_INTERVAL_MS", 10000), "How often the offset to the State Server clock is measured (0 disables clock sync)")
+	flag.IntVar(&cfg.SyncLeadMs, "sync-lead-ms", envInt("DEPLOYABLE_SYNC_LEAD_MS", 500), "How long before a state update's effective_at its state is entered and media cued")
+	flag.StringVar(&cfg.PluginsDir, "plugins-dir", envOrDefault("DEPLOYABLE_PLUGINS_DIR", "./plugins"), "Directory of plugin executables (optional)")
+	flag.BoolVar(&cfg.Journal, "journal", envBool("DEPLOYABLE_JOURNAL", false), "Record sensor events and state updates to a journal in the data directory")
+	flag.StringVar(&cfg.ReplayPath, "replay", envOrDefault("DEPLOYABLE_REPLAY", ""), "Replay a journal file into the engine instead of connecting to a State Server")
+	flag.Float64Var(&cfg.ReplaySpeed, "replay-speed", envFloat("DEPLOYABLE_REPLAY_SPEED", 1), "Replay speed multiplier (0 replays without delays)")
+	flag.BoolVar(&cfg.SensorPanel, "sensor-panel", envBool("DEPLOYABLE_SENSOR_PANEL", false), "Serve the virtual sensor panel at /panel (rehearsals only; events are not authenticated)")
+	flag.StringVar(&cfg.StateSource, "state-source", envOrDefault("DEPLOYABLE_STATE_SOURCE", "server"), "Where global state comes from: server or osc")
+	flag.StringVar(&cfg.OSCListenAddr, "osc-listen", envOrDefault("DEPLOYABLE_OSC_LISTEN", ":57121"), "UDP address to receive conductor OSC messages on (osc state source)")
+	flag.IntVar(&cfg.OSCHeartbeatTimeoutMs, "osc-heartbeat-timeout-ms", envInt("DEPLOYABLE_OSC_HEARTBEAT_TIMEOUT_MS", 15000), "Report the conductor lost after this long without an OSC heartbeat")
+	allowedCommands := flag.String("allowed-commands", envOrDefault("DEPLOYABLE_ALLOWED_COMMANDS", ""), "Absolute paths of programs the profile may declare as commands, separated by the OS path list separator")
+	allowedCommandEnv := flag.String("allowed-command-env", envOrDefault("DEPLOYABLE_ALLOWED_COMMAND_ENV", ""), "Comma-separated environment variable names declared commands may set")
+	flag.StringVar(&cfg.CommandWorkDirRoot, "command-work-dir-root", envOrDefault("DEPLOYABLE_COMMAND_WORK_DIR_ROOT", ""), "Directory declared commands' work_dir must be inside (empty: work_dir not allowed)")
 	flag.Parse()
 
 	cfg.ServerURL = strings.TrimSpace(cfg.ServerURL)
@@ -46,10 +96,35 @@ func Load() Config {
 	cfg.AgentVersion = strings.TrimSpace(cfg.AgentVersion)
 	cfg.PlaybackBackend = strings.ToLower(strings.TrimSpace(cfg.PlaybackBackend))
 	cfg.VLCPath = strings.TrimSpace(cfg.VLCPath)
+	cfg.VLCRestart = strings.ToLower(strings.TrimSpace(cfg.VLCRestart))
+	cfg.PluginsDir = strings.TrimSpace(cfg.PluginsDir)
+	cfg.ReplayPath = strings.TrimSpace(cfg.ReplayPath)
+	cfg.StateSource = strings.ToLower(strings.TrimSpace(cfg.StateSource))
+	cfg.OSCListenAddr = strings.TrimSpace(cfg.OSCListenAddr)
+	cfg.MPVPath = strings.TrimSpace(cfg.MPVPath)
+	cfg.MPVAudioDevices = parsePairs(*mpvAudioDevices)
+	cfg.TimelineFile = strings.TrimSpace(cfg.TimelineFile)
+	cfg.AllowedCommands = filepath.SplitList(strings.TrimSpace(*allowedCommands))
+	cfg.AllowedCommandEnv = strings.Split(*allowedCommandEnv, ",")
+	cfg.CommandWorkDirRoot = strings.TrimSpace(cfg.CommandWorkDirRoot)
 
 	return cfg
 }
 
+// parsePairs reads "key=value;key=value". Values may contain '=' and ','
+// as mpv device names do.
+func parsePairs(value string) map[string]string {
+	pairs := map[string]string{}
+	for _, item := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(item, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if ok && key != "" && val != "" {
+			pairs[key] = val
+		}
+	}
+	return pairs
+}
+
 func envOrDefault(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -65,3 +140,21 @@ func envBool(key string, def bool) bool {
 	return def
 }
 
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
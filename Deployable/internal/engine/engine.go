@@ -3,6 +3,7 @@ package engine
 import (
 	"errors"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ type Engine struct {
 	currentState string
 	actions      chan types.EngineAction
 	timers       map[string]*time.Timer
+	timerScale   float64
 	running      bool
 }
 
@@ -81,6 +83,14 @@ func (e *Engine) Stop() {
 	e.currentState = ""
 }
 
+// SetTimerScale makes state timers run scale times faster, as replay does.
+// Zero leaves them at their declared delays.
+func (e *Engine) SetTimerScale(scale float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.timerScale = scale
+}
+
 func (e *Engine) Actions() <-chan types.EngineAction {
 	return e.actions
 }
@@ -105,8 +115,22 @@ func (e *Engine) OnGlobalState(update types.GlobalStateUpdate) {
 		return
 	}
 
+	onExit, onEnter := prevState.OnExit, nextState.OnEnter
+	timed := map[string]any{}
+	if update.EffectiveAt != nil {
+		timed["effective_at"] = update.EffectiveAt.UTC().Format(time.RFC3339Nano)
+	}
+	if nextState.CrossfadeMs > 0 {
+		timed["crossfade_ms"] = nextState.CrossfadeMs
+		onExit, onEnter = handoverActions(onExit, onEnter, timed)
+	} else if len(timed) > 0 {
+		// A timed start alone keeps on_exit as written; its stops run
+		// when the state is entered.
+		onEnter = withPlayParams(onEnter, timed)
+	}
+
 	if prevStateName != "" {
-		e.executeActions(prevState.OnExit)
+		e.executeActions(onExit)
 		e.cancelTimers()
 	}
 
@@ -114,7 +138,7 @@ func (e *Engine) OnGlobalState(update types.GlobalStateUpdate) {
 	e.currentState = nextStateName
 	e.mu.Unlock()
 
-	e.executeActions(nextState.OnEnter)
+	e.executeActions(onEnter)
 	e.armTimers(nextState)
 }
 
@@ -136,8 +160,126 @@ func (e *Engine) OnSensorEvent(event types.SensorEvent) {
 		if !evaluateCondition(handler.Condition, event.Value) {
 			continue
 		}
-		e.executeActions(handler.Actions)
+		e.executeActions(withEvent(handler.Actions, event))
+	}
+}
+
+// withEvent exposes the triggering sensor event as params.event to handler
+// actions that render templates, such as those with a body_template param
+// or command.run. Other actions get their params unchanged.
+func withEvent(templates []types.ActionTemplate, event types.SensorEvent) []types.ActionTemplate {
+	out := make([]types.ActionTemplate, len(templates))
+	for i, action := range templates {
+		if !templatedActions[action.Action] && !hasTemplate(action.Params) {
+			out[i] = action
+			continue
+		}
+		params := make(map[string]any, len(action.Params)+1)
+		for key, value := range action.Params {
+			params[key] = value
+		}
+		if _, exists := params["event"]; !exists {
+			params["event"] = map[string]any{
+				"device_id":   event.DeviceID,
+				"sensor_id":   event.SensorID,
+				"sensor_type": event.SensorType,
+				"event_type":  event.EventType,
+				"value":       event.Value,
+				"timestamp":   event.Timestamp,
+			}
+		}
+		action.Params = params
+		out[i] = action
 	}
+	return out
+}
+
+// templatedActions render templates declared outside the show logic, as
+// command.run does with a command's args, so they always get the event.
+var templatedActions = map[string]bool{"command.run": true}
+
+func hasTemplate(params map[string]any) bool {
+	for key := range params {
+		if strings.HasSuffix(key, "_template") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	playActions = map[string]bool{"play_video": true, "play_audio": true, "media.play": true}
+	stopActions = map[string]bool{"stop_video": true, "stop_audio": true, "media.stop": true}
+)
+
+// handoverActions hands outputs from one state's media to the next's for a
+// crossfade: play actions on enter get the extra params, and on exit stops
+// on the same outputs are dropped so the new media replaces the old one
+// rather than following a stop.
+func handoverActions(onExit, onEnter []types.ActionTemplate, extra map[string]any) ([]types.ActionTemplate, []types.ActionTemplate) {
+	targets := map[string]bool{}
+	enter := withPlayParams(onEnter, extra)
+	for _, action := range enter {
+		if playActions[action.Action] {
+			targets[action.Target] = true
+		}
+	}
+	exit := make([]types.ActionTemplate, 0, len(onExit))
+	for _, action := range onExit {
+		if stopActions[action.Action] && targets[action.Target] {
+			continue
+		}
+		exit = append(exit, action)
+	}
+	return exit, enter
+}
+
+// withPlayParams gives play actions the extra params they do not set
+// themselves, such as crossfade_ms or effective_at.
+func withPlayParams(actions []types.ActionTemplate, extra map[string]any) []types.ActionTemplate {
+	out := make([]types.ActionTemplate, len(actions))
+	for i, action := range actions {
+		if playActions[action.Action] {
+			params := make(map[string]any, len(action.Params)+len(extra))
+			for key, value := range extra {
+				params[key] = value
+			}
+			for key, value := range action.Params {
+				params[key] = value
+			}
+			action.Params = params
+		}
+		out[i] = action
+	}
+	return out
+}
+
+// syncActions gathers play actions that share params.sync_group into one
+// play_sync action, in place of the first of them, so their outputs are
+// prepared and started together.
+func syncActions(actions []types.ActionTemplate) []types.ActionTemplate {
+	groups := map[string]int{}
+	out := make([]types.ActionTemplate, 0, len(actions))
+	for _, action := range actions {
+		group, _ := action.Params["sync_group"].(string)
+		if group == "" || !playActions[action.Action] {
+			out = append(out, action)
+			continue
+		}
+		item := map[string]any{"action": action.Action, "target": action.Target, "params": action.Params}
+		if idx, ok := groups[group]; ok {
+			items := out[idx].Params["items"].([]any)
+			out[idx].Params["items"] = append(items, item)
+			continue
+		}
+		groups[group] = len(out)
+		out = append(out, types.ActionTemplate{
+			Action: "play_sync",
+			Target: group,
+			Params: map[string]any{"items": []any{item}},
+		})
+	}
+	return out
 }
 
 func (e *Engine) OnTimer(event types.TimerEvent) {
@@ -156,7 +298,7 @@ func (e *Engine) OnTimer(event types.TimerEvent) {
 }
 
 func (e *Engine) executeActions(actions []types.ActionTemplate) {
-	for _, action := range actions {
+	for _, action := range syncActions(actions) {
 		e.actions <- types.EngineAction{
 			Action: action.Action,
 			Target: action.Target,
@@ -174,6 +316,9 @@ func (e *Engine) armTimers(state types.ShowState) {
 		if delay <= 0 {
 			continue
 		}
+		if e.timerScale > 0 {
+			delay = time.Duration(float64(delay) / e.timerScale)
+		}
 		t := time.AfterFunc(delay, func() {
 			e.OnTimer(types.TimerEvent{
 				TimerID:   timerID,
@@ -0,0 +1,191 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+)
+
+const (
+	KindHeader = "header"
+	KindSensor = "sensor"
+	KindState  = "state"
+)
+
+// Entry is one line of a journal. Exactly one of Sensor and State is set
+// for sensor and state entries; the header carries only DeviceID.
+type Entry struct {
+	At       time.Time                `json:"t"`
+	Kind     string                   `json:"k"`
+	DeviceID string                   `json:"device_id,omitempty"`
+	Sensor   *types.SensorEvent       `json:"sensor,omitempty"`
+	State    *types.GlobalStateUpdate `json:"state,omitempty"`
+}
+
+// Recorder appends entries to a JSON lines file from a background goroutine
+// so recording never holds up the sensor or state path.
+type Recorder struct {
+	path    string
+	entries chan Entry
+	done    chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewRecorder starts a new journal file in dir, named after the current
+// time so successive runs never overwrite each other.
+func NewRecorder(dir, deviceID string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	path := filepath.Join(dir, "journal-"+now.Format("20060102T150405Z")+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		path:    path,
+		entries: make(chan Entry, 1024),
+		done:    make(chan struct{}),
+	}
+	r.entries <- Entry{At: now, Kind: KindHeader, DeviceID: deviceID}
+	go r.write(file)
+	return r, nil
+}
+
+func (r *Recorder) Path() string {
+	return r.path
+}
+
+func (r *Recorder) RecordSensor(event types.SensorEvent) {
+	r.record(Entry{At: time.Now().UTC(), Kind: KindSensor, Sensor: &event})
+}
+
+func (r *Recorder) RecordState(update types.GlobalStateUpdate) {
+	r.record(Entry{At: time.Now().UTC(), Kind: KindState, State: &update})
+}
+
+func (r *Recorder) record(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.entries <- entry:
+	default:
+		log.Printf("journal: buffer full, dropping %s entry", entry.Kind)
+	}
+}
+
+// Close flushes pending entries and closes the file.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.entries)
+	r.mu.Unlock()
+	<-r.done
+}
+
+func (r *Recorder) write(file *os.File) {
+	defer close(r.done)
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for entry := range r.entries {
+		if err := encoder.Encode(entry); err != nil {
+			log.Printf("journal: write failed: %v", err)
+			continue
+		}
+		// Flush once the backlog is drained so a crash loses at most the
+		// entries still queued.
+		if len(r.entries) == 0 {
+			if err := writer.Flush(); err != nil {
+				log.Printf("journal: flush failed: %v", err)
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		log.Printf("journal: flush failed: %v", err)
+	}
+}
+
+type Sink interface {
+	ReplaySensorEvent(event types.SensorEvent)
+	ReplayState(update types.GlobalStateUpdate)
+}
+
+// Replay feeds a journal into sink, preserving the recorded gaps between
+// entries divided by speed. A speed of zero or less replays without
+// waiting. A truncated final line, as left by a crash, ends the replay
+// without error.
+func Replay(ctx context.Context, path string, speed float64, sink Sink) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var first, start time.Time
+	line := 0
+	for {
+		raw, readErr := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			line++
+			var entry Entry
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				if errors.Is(readErr, io.EOF) {
+					log.Printf("journal: ignoring truncated last line %d", line)
+					return nil
+				}
+				return fmt.Errorf("journal line %d: %w", line, err)
+			}
+			if first.IsZero() {
+				first, start = entry.At, time.Now()
+			}
+			if speed > 0 {
+				due := start.Add(time.Duration(float64(entry.At.Sub(first)) / speed))
+				if wait := time.Until(due); wait > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return ctx.Err()
+					case <-timer.C:
+					}
+				}
+			} else if err := ctx.Err(); err != nil {
+				return err
+			}
+			switch {
+			case entry.Kind == KindSensor && entry.Sensor != nil:
+				sink.ReplaySensorEvent(*entry.Sensor)
+			case entry.Kind == KindState && entry.State != nil:
+				sink.ReplayState(*entry.State)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
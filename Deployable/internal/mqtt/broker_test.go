@@ -0,0 +1,442 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker is an in-process MQTT 3.1.1 broker stand-in. Clients reach it
+// through Options.Dial over a net.Pipe. It routes publishes between its
+// sessions and records what it received for tests to assert on.
+type fakeBroker struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	sessions map[*brokerSession]bool
+	connects []connectPacket
+	subs     []string
+	received []packet
+	acked    []uint16
+	refuse   byte
+	changed  chan struct{}
+}
+
+type connectPacket struct {
+	clientID string
+	username string
+	password string
+}
+
+type brokerSession struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	filters map[string]byte
+	nextID  uint16
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	b := &fakeBroker{
+		t:        t,
+		sessions: make(map[*brokerSession]bool),
+		changed:  make(chan struct{}, 1),
+	}
+	t.Cleanup(b.dropAll)
+	return b
+}
+
+// dial is an Options.Dial that connects a new session.
+func (b *fakeBroker) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	session := &brokerSession{conn: server, filters: make(map[string]byte)}
+	b.mu.Lock()
+	b.sessions[session] = true
+	b.mu.Unlock()
+	go b.serve(session)
+	return client, nil
+}
+
+func (b *fakeBroker) serve(s *brokerSession) {
+	defer func() {
+		_ = s.conn.Close()
+		b.mu.Lock()
+		delete(b.sessions, s)
+		b.mu.Unlock()
+		b.notify()
+	}()
+	reader := bufio.NewReader(s.conn)
+	for {
+		header, body, err := readFrame(reader)
+		if err != nil {
+			return
+		}
+		d := decoder{buf: body}
+		switch header >> 4 {
+		case packetConnect:
+			d.string()
+			d.byte()
+			flags := d.byte()
+			d.uint16()
+			connect := connectPacket{clientID: d.string()}
+			if flags&0x80 != 0 {
+				connect.username = d.string()
+			}
+			if flags&0x40 != 0 {
+				connect.password = d.string()
+			}
+			b.mu.Lock()
+			b.connects = append(b.connects, connect)
+			code := b.refuse
+			b.refuse = 0
+			b.mu.Unlock()
+			s.write(frame(packetConnack<<4, []byte{0, code}))
+			b.notify()
+			if code != 0 {
+				return
+			}
+		case packetSubscribe:
+			id := d.uint16()
+			filter := d.string()
+			qos := d.byte()
+			s.mu.Lock()
+			s.filters[filter] = qos
+			s.mu.Unlock()
+			b.mu.Lock()
+			b.subs = append(b.subs, filter)
+			b.mu.Unlock()
+			s.write(frame(packetSuback<<4, append(binary.BigEndian.AppendUint16(nil, id), qos)))
+			b.notify()
+		case packetUnsubscribe:
+			id := d.uint16()
+			filter := d.string()
+			s.mu.Lock()
+			delete(s.filters, filter)
+			s.mu.Unlock()
+			s.write(frame(packetUnsuback<<4, binary.BigEndian.AppendUint16(nil, id)))
+		case packetPublish:
+			p := packet{qos: (header >> 1) & 0x03, retain: header&0x01 != 0}
+			p.topic = d.string()
+			if p.qos > 0 {
+				p.packetID = d.uint16()
+			}
+			p.payload = d.rest()
+			b.mu.Lock()
+			b.received = append(b.received, p)
+			b.mu.Unlock()
+			b.notify()
+			if p.qos == 1 {
+				s.write(encodeAck(packetPuback, p.packetID))
+			}
+			b.Publish(p.topic, p.payload, p.qos)
+		case packetPuback:
+			b.mu.Lock()
+			b.acked = append(b.acked, d.uint16())
+			b.mu.Unlock()
+			b.notify()
+		case packetPingreq:
+			s.write(frame(packetPingresp<<4, nil))
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+// Publish sends a message to every session subscribed to topic, at the
+// lower of qos and the subscription's QoS.
+func (b *fakeBroker) Publish(topic string, payload []byte, qos byte) {
+	b.mu.Lock()
+	sessions := make([]*brokerSession, 0, len(b.sessions))
+	for s := range b.sessions {
+		sessions = append(sessions, s)
+	}
+	b.mu.Unlock()
+	for _, s := range sessions {
+		s.mu.Lock()
+		granted, matched := byte(0), false
+		for filter, subQoS := range s.filters {
+			if topicMatches(filter, topic) {
+				matched = true
+				if subQoS > granted {
+					granted = subQoS
+				}
+			}
+		}
+		p := packet{topic: topic, payload: payload, qos: min(qos, granted)}
+		if matched && p.qos > 0 {
+			s.nextID++
+			p.packetID = s.nextID
+		}
+		s.mu.Unlock()
+		if matched {
+			s.write(encodePublish(ProtocolV311, p))
+		}
+	}
+}
+
+// dropAll closes every session, as a broker restart would.
+func (b *fakeBroker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.sessions {
+		_ = s.conn.Close()
+	}
+}
+
+func (b *fakeBroker) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// waitFor polls cond, under the broker lock, each time the broker's state
+// changes until it holds or the test times out.
+func (b *fakeBroker) waitFor(what string, cond func() bool) {
+	b.t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		b.mu.Lock()
+		ok := cond()
+		b.mu.Unlock()
+		if ok {
+			return
+		}
+		select {
+		case <-b.changed:
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			b.t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func (s *brokerSession) write(data []byte) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.conn.Write(data)
+}
+
+func readFrame(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func startClient(t *testing.T, b *fakeBroker, opts Options) *Client {
+	t.Helper()
+	opts.Dial = b.dial
+	if opts.ClientID == "" {
+		opts.ClientID = "deployable-test"
+	}
+	client := NewClient(opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return client
+}
+
+func waitConnected(t *testing.T, c *Client, connects int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		status := c.Status()
+		if status["connected"] == true && status["connects"].(int) >= connects {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("client not connected %d time(s): %v", connects, c.Status())
+}
+
+func receive(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(3 * time.Second):
+		t.Fatal("no message delivered")
+		return Message{}
+	}
+}
+
+func TestClientConnectSendsCredentials(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue", ClientID: "wall", Username: "show", Password: "secret"})
+	waitConnected(t, c, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	want := connectPacket{clientID: "wall", username: "show", password: "secret"}
+	if len(b.connects) != 1 || b.connects[0] != want {
+		t.Fatalf("connects = %+v, want [%+v]", b.connects, want)
+	}
+}
+
+func TestClientRefusedConnectRetries(t *testing.T) {
+	b := newFakeBroker(t)
+	b.refuse = 0x05
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	b.waitFor("refused connect", func() bool { return len(b.connects) == 1 })
+	waitConnected(t, c, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.connects) != 2 {
+		t.Fatalf("connects = %d, want 2", len(b.connects))
+	}
+}
+
+func TestClientSubscribeDeliversMatchingMessages(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	messages := make(chan Message, 4)
+	c.Subscribe("venue/+/state", 0, func(msg Message) { messages <- msg })
+	waitConnected(t, c, 1)
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 1 })
+
+	b.Publish("venue/lobby/level", []byte("ignored"), 0)
+	b.Publish("venue/door/state", []byte("open"), 0)
+	msg := receive(t, messages)
+	if msg.Topic != "venue/door/state" || string(msg.Payload) != "open" {
+		t.Fatalf("got %s %q, want venue/door/state \"open\"", msg.Topic, msg.Payload)
+	}
+}
+
+func TestClientUnsubscribeStopsDelivery(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	waitConnected(t, c, 1)
+	messages := make(chan Message, 4)
+	unsubscribe := c.Subscribe("venue/door", 0, func(msg Message) { messages <- msg })
+	keep := make(chan Message, 4)
+	c.Subscribe("venue/keep", 0, func(msg Message) { keep <- msg })
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 2 })
+
+	unsubscribe()
+	b.Publish("venue/door", []byte("open"), 0)
+	b.Publish("venue/keep", []byte("ok"), 0)
+	receive(t, keep)
+	select {
+	case msg := <-messages:
+		t.Fatalf("unsubscribed handler got %s", msg.Topic)
+	default:
+	}
+}
+
+func TestClientPublishQoS1WaitsForPuback(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	waitConnected(t, c, 1)
+	if err := c.Publish("venue/cue", []byte("go"), 1, false); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.received) != 1 {
+		t.Fatalf("broker received %d messages, want 1", len(b.received))
+	}
+	got := b.received[0]
+	if got.topic != "venue/cue" || got.qos != 1 || got.packetID == 0 || string(got.payload) != "go" {
+		t.Fatalf("broker received %+v", got)
+	}
+}
+
+func TestClientAcksQoS1Messages(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	messages := make(chan Message, 4)
+	c.Subscribe("venue/door", 1, func(msg Message) { messages <- msg })
+	waitConnected(t, c, 1)
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 1 })
+
+	b.Publish("venue/door", []byte("open"), 1)
+	if msg := receive(t, messages); msg.QoS != 1 {
+		t.Fatalf("qos = %d, want 1", msg.QoS)
+	}
+	b.waitFor("puback", func() bool { return len(b.acked) == 1 })
+}
+
+func TestClientAcksWhileHandlerBlocks(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	release := make(chan struct{})
+	defer close(release)
+	c.Subscribe("venue/door", 1, func(Message) { <-release })
+	waitConnected(t, c, 1)
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 1 })
+
+	b.Publish("venue/door", []byte("1"), 1)
+	b.Publish("venue/door", []byte("2"), 1)
+	b.waitFor("pubacks behind a blocked handler", func() bool { return len(b.acked) == 2 })
+}
+
+func TestClientReconnectRestoresSubscriptions(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue"})
+	messages := make(chan Message, 4)
+	c.Subscribe("venue/door", 1, func(msg Message) { messages <- msg })
+	waitConnected(t, c, 1)
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 1 })
+
+	b.dropAll()
+	waitConnected(t, c, 2)
+	b.waitFor("resubscribe", func() bool { return len(b.subs) == 2 })
+	b.Publish("venue/door", []byte("closed"), 1)
+	if msg := receive(t, messages); string(msg.Payload) != "closed" {
+		t.Fatalf("payload = %q, want \"closed\"", msg.Payload)
+	}
+}
+
+func TestClientDropsOversizedPackets(t *testing.T) {
+	b := newFakeBroker(t)
+	c := startClient(t, b, Options{BrokerID: "venue", MaxPacketSize: 64})
+	messages := make(chan Message, 4)
+	c.Subscribe("venue/door", 0, func(msg Message) { messages <- msg })
+	waitConnected(t, c, 1)
+	b.waitFor("subscribe", func() bool { return len(b.subs) == 1 })
+
+	b.Publish("venue/door", make([]byte, 128), 0)
+	waitConnected(t, c, 2)
+	select {
+	case <-messages:
+		t.Fatal("oversized message was delivered")
+	default:
+	}
+}
+
+func TestReadPacketRejectsLengthOverLimit(t *testing.T) {
+	// Only the start of the packet is there, so reading its body would
+	// fail with an unexpected EOF.
+	data := frame(packetPublish<<4, make([]byte, 300))[:8]
+	_, err := readPacket(bufio.NewReader(bytes.NewReader(data)), ProtocolV311, 256)
+	if err == nil {
+		t.Fatal("expected an error for a packet over the limit")
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("body was read before the limit was checked: %v", err)
+	}
+}
+
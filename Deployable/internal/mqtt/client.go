@@ -0,0 +1,496 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKeepAlive   = 30 * time.Second
+	defaultAckTimeout  = 5 * time.Second
+	defaultDialTimeout = 5 * time.Second
+	// DefaultMaxPacketSize bounds packets read from the broker.
+	DefaultMaxPacketSize = 1 << 20
+	// deliveryQueue is how many received messages may wait for their
+	// handlers before further ones are dropped.
+	deliveryQueue = 256
+)
+
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+type Options struct {
+	BrokerID        string
+	URL             string
+	ClientID        string
+	Username        string
+	Password        string
+	ProtocolVersion byte
+	KeepAlive       time.Duration
+	CleanSession    bool
+	MaxPacketSize   int
+	// Dial overrides the network connection, e.g. with one end of a
+	// net.Pipe served by an in-process broker.
+	Dial func(ctx context.Context) (net.Conn, error)
+}
+
+type delivery struct {
+	msg      Message
+	handlers []func(Message)
+}
+
+type subscription struct {
+	qos      byte
+	handlers map[int]func(Message)
+}
+
+type Client struct {
+	opts       Options
+	deliveries chan delivery
+
+	mu         sync.Mutex
+	conn       net.Conn
+	writeMu    sync.Mutex
+	nextID     uint16
+	pending    map[uint16]chan packet
+	inflight   map[uint16]bool
+	subs       map[string]*subscription
+	nextHandle int
+	connected  bool
+	lastError  string
+	connects   int
+}
+
+func NewClient(opts Options) *Client {
+	if opts.ProtocolVersion == 0 {
+		opts.ProtocolVersion = ProtocolV311
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = defaultKeepAlive
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = DefaultMaxPacketSize
+	}
+	return &Client{
+		opts:       opts,
+		deliveries: make(chan delivery, deliveryQueue),
+		pending:    make(map[uint16]chan packet),
+		inflight:   make(map[uint16]bool),
+		subs:       make(map[string]*subscription),
+	}
+}
+
+// Run keeps the broker session alive until ctx is cancelled, reconnecting
+// with exponential backoff and restoring subscriptions after each connect.
+func (c *Client) Run(ctx context.Context) {
+	go c.deliver(ctx)
+	backoff := time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		var reader *bufio.Reader
+		conn, err := c.dial(ctx)
+		if err == nil {
+			reader, err = c.handshake(conn)
+			if err != nil {
+				_ = conn.Close()
+			}
+		}
+		if err != nil {
+			c.setError(err)
+			log.Printf("mqtt broker %s connect failed: %v", c.opts.BrokerID, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+		log.Printf("mqtt broker %s connected", c.opts.BrokerID)
+		err = c.serve(ctx, conn, reader)
+		c.disconnect(conn)
+		if err != nil && ctx.Err() == nil {
+			c.setError(err)
+			log.Printf("mqtt broker %s connection lost: %v", c.opts.BrokerID, err)
+		}
+	}
+}
+
+func (c *Client) Publish(topic string, payload []byte, qos byte, retain bool) error {
+	if qos > 2 {
+		return fmt.Errorf("invalid mqtt qos: %d", qos)
+	}
+	c.mu.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mu.Unlock()
+		return fmt.Errorf("mqtt broker %s not connected", c.opts.BrokerID)
+	}
+	p := packet{topic: topic, payload: payload, qos: qos, retain: retain}
+	var ack chan packet
+	if qos > 0 {
+		p.packetID = c.allocateIDLocked()
+		ack = make(chan packet, 2)
+		c.pending[p.packetID] = ack
+	}
+	c.mu.Unlock()
+
+	if err := c.write(conn, encodePublish(c.opts.ProtocolVersion, p)); err != nil {
+		c.release(p.packetID)
+		return err
+	}
+	if qos == 0 {
+		return nil
+	}
+	defer c.release(p.packetID)
+	timeout := time.After(defaultAckTimeout)
+	for {
+		select {
+		case resp, ok := <-ack:
+			if !ok {
+				return fmt.Errorf("mqtt broker %s disconnected before ack", c.opts.BrokerID)
+			}
+			if resp.reason >= 0x80 {
+				return fmt.Errorf("mqtt publish rejected: reason 0x%02x", resp.reason)
+			}
+			switch resp.kind {
+			case packetPuback, packetPubcomp:
+				return nil
+			case packetPubrec:
+				if err := c.write(conn, encodeAck(packetPubrel, p.packetID)); err != nil {
+					return err
+				}
+			}
+		case <-timeout:
+			return fmt.Errorf("mqtt publish to %s timed out", topic)
+		}
+	}
+}
+
+// Subscribe registers handler for messages matching filter and returns a
+// function that removes it again. Subscriptions survive reconnects.
+func (c *Client) Subscribe(filter string, qos byte, handler func(Message)) func() {
+	c.mu.Lock()
+	sub, exists := c.subs[filter]
+	if !exists {
+		sub = &subscription{qos: qos, handlers: make(map[int]func(Message))}
+		c.subs[filter] = sub
+	}
+	if qos > sub.qos {
+		sub.qos = qos
+	}
+	c.nextHandle++
+	handle := c.nextHandle
+	sub.handlers[handle] = handler
+	subQoS := sub.qos
+	conn := c.conn
+	var id uint16
+	if conn != nil {
+		id = c.allocateIDLocked()
+	}
+	c.mu.Unlock()
+	if conn != nil {
+		_ = c.write(conn, encodeSubscribe(c.opts.ProtocolVersion, id, filter, subQoS))
+	}
+
+	return func() {
+		c.mu.Lock()
+		sub, ok := c.subs[filter]
+		if !ok {
+			c.mu.Unlock()
+			return
+		}
+		delete(sub.handlers, handle)
+		if len(sub.handlers) > 0 {
+			c.mu.Unlock()
+			return
+		}
+		delete(c.subs, filter)
+		conn := c.conn
+		var id uint16
+		if conn != nil {
+			id = c.allocateIDLocked()
+		}
+		c.mu.Unlock()
+		if conn != nil {
+			_ = c.write(conn, encodeUnsubscribe(c.opts.ProtocolVersion, id, filter))
+		}
+	}
+}
+
+func (c *Client) Status() map[string]any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	topics := make([]string, 0, len(c.subs))
+	for filter := range c.subs {
+		topics = append(topics, filter)
+	}
+	return map[string]any{
+		"broker_id":     c.opts.BrokerID,
+		"url":           RedactURL(c.opts.URL),
+		"connected":     c.connected,
+		"connects":      c.connects,
+		"last_error":    c.lastError,
+		"subscriptions": topics,
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	if c.opts.Dial != nil {
+		return c.opts.Dial(ctx)
+	}
+	u, err := url.Parse(c.opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: defaultDialTimeout}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		return dialer.DialContext(ctx, "tcp", hostWithPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}
+		return tlsDialer.DialContext(ctx, "tcp", hostWithPort(u, "8883"))
+	default:
+		return nil, fmt.Errorf("unsupported mqtt url scheme: %s", u.Scheme)
+	}
+}
+
+func (c *Client) handshake(conn net.Conn) (*bufio.Reader, error) {
+	_ = conn.SetDeadline(time.Now().Add(defaultAckTimeout))
+	defer conn.SetDeadline(time.Time{})
+	keepAlive := uint16(c.opts.KeepAlive / time.Second)
+	if _, err := conn.Write(encodeConnect(connectOptions{
+		protocol:     c.opts.ProtocolVersion,
+		clientID:     c.opts.ClientID,
+		username:     c.opts.Username,
+		password:     c.opts.Password,
+		keepAlive:    keepAlive,
+		cleanSession: c.opts.CleanSession,
+	})); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	ack, err := readPacket(reader, c.opts.ProtocolVersion, c.opts.MaxPacketSize)
+	if err != nil {
+		return nil, err
+	}
+	if ack.kind != packetConnack {
+		return nil, fmt.Errorf("expected connack, got packet type %d", ack.kind)
+	}
+	if ack.reason != 0 {
+		return nil, fmt.Errorf("mqtt connect refused: code 0x%02x", ack.reason)
+	}
+	return reader, nil
+}
+
+func (c *Client) serve(ctx context.Context, conn net.Conn, reader *bufio.Reader) error {
+	c.mu.Lock()
+	c.conn = conn
+	c.connected = true
+	c.connects++
+	c.lastError = ""
+	resubscribe := make(map[string]byte, len(c.subs))
+	ids := make(map[string]uint16, len(c.subs))
+	for filter, sub := range c.subs {
+		resubscribe[filter] = sub.qos
+		ids[filter] = c.allocateIDLocked()
+	}
+	c.mu.Unlock()
+	for filter, qos := range resubscribe {
+		if err := c.write(conn, encodeSubscribe(c.opts.ProtocolVersion, ids[filter], filter, qos)); err != nil {
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(c.opts.KeepAlive / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				_ = c.write(conn, encodeDisconnect())
+				_ = conn.Close()
+				return
+			case <-ticker.C:
+				if err := c.write(conn, encodePingreq()); err != nil {
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(c.opts.KeepAlive * 3 / 2))
+		p, err := readPacket(reader, c.opts.ProtocolVersion, c.opts.MaxPacketSize)
+		if err != nil {
+			return err
+		}
+		switch p.kind {
+		case packetPublish:
+			c.handlePublish(conn, p)
+		case packetPubrel:
+			c.mu.Lock()
+			delete(c.inflight, p.packetID)
+			c.mu.Unlock()
+			if err := c.write(conn, encodeAck(packetPubcomp, p.packetID)); err != nil {
+				return err
+			}
+		case packetPuback, packetPubrec, packetPubcomp:
+			c.mu.Lock()
+			ack := c.pending[p.packetID]
+			c.mu.Unlock()
+			if ack != nil {
+				ack <- p
+			}
+		case packetSuback:
+			for _, code := range p.reasons {
+				if code >= 0x80 {
+					log.Printf("mqtt broker %s rejected subscription (packet %d): 0x%02x", c.opts.BrokerID, p.packetID, code)
+				}
+			}
+		case packetDisconnect:
+			return errors.New("broker sent disconnect")
+		}
+	}
+}
+
+func (c *Client) handlePublish(conn net.Conn, p packet) {
+	switch p.qos {
+	case 1:
+		_ = c.write(conn, encodeAck(packetPuback, p.packetID))
+	case 2:
+		c.mu.Lock()
+		duplicate := c.inflight[p.packetID]
+		c.inflight[p.packetID] = true
+		c.mu.Unlock()
+		_ = c.write(conn, encodeAck(packetPubrec, p.packetID))
+		if duplicate {
+			return
+		}
+	}
+	msg := Message{Topic: p.topic, Payload: p.payload, QoS: p.qos, Retain: p.retain}
+	c.mu.Lock()
+	var handlers []func(Message)
+	for filter, sub := range c.subs {
+		if !topicMatches(filter, p.topic) {
+			continue
+		}
+		for _, handler := range sub.handlers {
+			handlers = append(handlers, handler)
+		}
+	}
+	c.mu.Unlock()
+	if len(handlers) == 0 {
+		return
+	}
+	// Handlers run off the read loop, so a slow one cannot hold up acks
+	// and keepalives.
+	select {
+	case c.deliveries <- delivery{msg: msg, handlers: handlers}:
+	default:
+		log.Printf("mqtt broker %s: handlers backed up, dropped message on %s", c.opts.BrokerID, msg.Topic)
+	}
+}
+
+// deliver runs subscription handlers in the order messages arrived.
+func (c *Client) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-c.deliveries:
+			for _, handler := range d.handlers {
+				handler(d.msg)
+			}
+		}
+	}
+}
+
+func (c *Client) disconnect(conn net.Conn) {
+	_ = conn.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = nil
+	c.connected = false
+	for id, ack := range c.pending {
+		close(ack)
+		delete(c.pending, id)
+	}
+	c.inflight = make(map[uint16]bool)
+}
+
+func (c *Client) write(conn net.Conn, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = conn.SetWriteDeadline(time.Now().Add(defaultAckTimeout))
+	_, err := conn.Write(data)
+	return err
+}
+
+func (c *Client) allocateIDLocked() uint16 {
+	for {
+		c.nextID++
+		if c.nextID == 0 {
+			continue
+		}
+		if _, busy := c.pending[c.nextID]; !busy {
+			return c.nextID
+		}
+	}
+}
+
+func (c *Client) release(id uint16) {
+	if id == 0 {
+		return
+	}
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *Client) setError(err error) {
+	c.mu.Lock()
+	c.lastError = err.Error()
+	c.mu.Unlock()
+}
+
+// RedactURL hides the password in a broker URL's userinfo.
+func RedactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
+}
+
+func hostWithPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
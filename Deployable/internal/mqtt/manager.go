@@ -0,0 +1,260 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"deployable/internal/sensors"
+	"deployable/internal/types"
+)
+
+type Publisher interface {
+	Publish(brokerID, topic string, payload []byte, qos byte, retain bool) error
+}
+
+type Manager struct {
+	mu      sync.Mutex
+	brokers map[string]*broker
+	order   []string
+}
+
+type broker struct {
+	config types.MQTTBroker
+	client *Client
+	cancel context.CancelFunc
+}
+
+func NewManager() *Manager {
+	return &Manager{brokers: make(map[string]*broker)}
+}
+
+func ValidateBrokers(brokers []types.MQTTBroker) error {
+	seen := map[string]bool{}
+	sensorIDs := map[string]bool{}
+	for _, cfg := range brokers {
+		if cfg.BrokerID == "" {
+			return errors.New("mqtt broker missing broker_id")
+		}
+		if seen[cfg.BrokerID] {
+			return errors.New("duplicate mqtt broker " + cfg.BrokerID)
+		}
+		seen[cfg.BrokerID] = true
+		u, err := url.Parse(cfg.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("mqtt broker %s has invalid url: %q", cfg.BrokerID, cfg.URL)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt", "ssl", "tls", "mqtts":
+		default:
+			return fmt.Errorf("mqtt broker %s has unsupported url scheme: %s", cfg.BrokerID, u.Scheme)
+		}
+		switch cfg.ProtocolVersion {
+		case 0, ProtocolV311, ProtocolV5:
+		default:
+			return fmt.Errorf("mqtt broker %s has unsupported protocol_version %d (use 4 for 3.1.1 or 5)", cfg.BrokerID, cfg.ProtocolVersion)
+		}
+		for _, sub := range cfg.Subscriptions {
+			if sub.SensorID == "" {
+				return fmt.Errorf("mqtt broker %s subscription missing sensor_id", cfg.BrokerID)
+			}
+			if sensorIDs[sub.SensorID] {
+				return errors.New("duplicate mqtt sensor " + sub.SensorID)
+			}
+			sensorIDs[sub.SensorID] = true
+			if sub.Topic == "" {
+				return fmt.Errorf("mqtt sensor %s missing topic", sub.SensorID)
+			}
+			if sub.QoS < 0 || sub.QoS > 2 {
+				return fmt.Errorf("mqtt sensor %s has invalid qos %d", sub.SensorID, sub.QoS)
+			}
+		}
+	}
+	return nil
+}
+
+// Configure starts a client per broker, keeping clients whose connection
+// settings are unchanged and shutting down the rest.
+func (m *Manager) Configure(brokers []types.MQTTBroker, defaultClientID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next := make(map[string]*broker, len(brokers))
+	order := make([]string, 0, len(brokers))
+	for _, cfg := range brokers {
+		order = append(order, cfg.BrokerID)
+		if existing, ok := m.brokers[cfg.BrokerID]; ok && sameConnection(existing.config, cfg) {
+			existing.config = cfg
+			next[cfg.BrokerID] = existing
+			continue
+		}
+		next[cfg.BrokerID] = startBroker(cfg, defaultClientID)
+	}
+	for id, existing := range m.brokers {
+		if next[id] != existing {
+			existing.cancel()
+		}
+	}
+	m.brokers = next
+	m.order = order
+}
+
+func (m *Manager) Publish(brokerID, topic string, payload []byte, qos byte, retain bool) error {
+	client, err := m.client(brokerID)
+	if err != nil {
+		return err
+	}
+	return client.Publish(topic, payload, qos, retain)
+}
+
+// Sensors returns a subscription sensor for every topic declared on the
+// configured brokers.
+func (m *Manager) Sensors() []sensors.Sensor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []sensors.Sensor{}
+	for _, id := range m.order {
+		b := m.brokers[id]
+		for _, sub := range b.config.Subscriptions {
+			out = append(out, &SubscriptionSensor{
+				client: b.client,
+				broker: id,
+				config: sub,
+			})
+		}
+	}
+	return out
+}
+
+func (m *Manager) Snapshot() []map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]map[string]any, 0, len(m.order))
+	for _, id := range m.order {
+		out = append(out, m.brokers[id].client.Status())
+	}
+	return out
+}
+
+func (m *Manager) client(brokerID string) (*Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if brokerID == "" {
+		if len(m.order) != 1 {
+			return nil, errors.New("mqtt broker must be specified when zero or multiple brokers are configured")
+		}
+		brokerID = m.order[0]
+	}
+	b, ok := m.brokers[brokerID]
+	if !ok {
+		return nil, fmt.Errorf("mqtt broker not configured: %s", brokerID)
+	}
+	return b.client, nil
+}
+
+func startBroker(cfg types.MQTTBroker, defaultClientID string) *broker {
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+	clean := true
+	if cfg.CleanSession != nil {
+		clean = *cfg.CleanSession
+	}
+	client := NewClient(Options{
+		BrokerID:        cfg.BrokerID,
+		URL:             cfg.URL,
+		ClientID:        clientID,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		ProtocolVersion: byte(cfg.ProtocolVersion),
+		KeepAlive:       time.Duration(cfg.KeepAliveSec) * time.Second,
+		CleanSession:    clean,
+		MaxPacketSize:   cfg.MaxPacketBytes,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go client.Run(ctx)
+	return &broker{config: cfg, client: client, cancel: cancel}
+}
+
+func sameConnection(a, b types.MQTTBroker) bool {
+	a.Subscriptions = nil
+	b.Subscriptions = nil
+	return reflect.DeepEqual(a, b)
+}
+
+type SubscriptionSensor struct {
+	client *Client
+	broker string
+	config types.MQTTSubscription
+
+	mu          sync.Mutex
+	unsubscribe func()
+}
+
+func (s *SubscriptionSensor) ID() string {
+	return s.config.SensorID
+}
+
+func (s *SubscriptionSensor) Type() string {
+	return "mqtt"
+}
+
+func (s *SubscriptionSensor) Capabilities() map[string]any {
+	return map[string]any{
+		"broker_id": s.broker,
+		"topic":     s.config.Topic,
+		"qos":       s.config.QoS,
+	}
+}
+
+func (s *SubscriptionSensor) Start(eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unsubscribe != nil {
+		return
+	}
+	eventType := s.config.EventType
+	if eventType == "" {
+		eventType = "message"
+	}
+	s.unsubscribe = s.client.Subscribe(s.config.Topic, byte(s.config.QoS), func(msg Message) {
+		eventSink <- types.SensorEvent{
+			SensorID:   s.config.SensorID,
+			SensorType: "mqtt",
+			EventType:  eventType,
+			Value:      decodePayload(msg.Payload),
+			Timestamp:  time.Now().UTC(),
+		}
+	})
+}
+
+func (s *SubscriptionSensor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unsubscribe != nil {
+		s.unsubscribe()
+		s.unsubscribe = nil
+	}
+}
+
+// decodePayload yields JSON values where the payload parses as JSON so that
+// handler conditions can compare numbers and booleans, and the raw text
+// otherwise.
+func decodePayload(payload []byte) any {
+	text := strings.TrimSpace(string(payload))
+	if text == "" {
+		return ""
+	}
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err == nil {
+		return value
+	}
+	return string(payload)
+}
+
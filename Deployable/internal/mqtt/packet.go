@@ -0,0 +1,347 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	ProtocolV311 = 4
+	ProtocolV5   = 5
+)
+
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+)
+
+const maxRemainingLength = 268435455
+
+type packet struct {
+	kind     byte
+	flags    byte
+	packetID uint16
+	topic    string
+	payload  []byte
+	qos      byte
+	retain   bool
+	dup      bool
+	reason   byte
+	reasons  []byte
+}
+
+type connectOptions struct {
+	protocol     byte
+	clientID     string
+	username     string
+	password     string
+	keepAlive    uint16
+	cleanSession bool
+}
+
+func encodeConnect(opts connectOptions) []byte {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, opts.protocol)
+	var flags byte
+	if opts.cleanSession {
+		flags |= 0x02
+	}
+	if opts.username != "" {
+		flags |= 0x80
+	}
+	if opts.password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, opts.keepAlive)
+	if opts.protocol == ProtocolV5 {
+		body = appendVarInt(body, 0)
+	}
+	body = appendString(body, opts.clientID)
+	if opts.username != "" {
+		body = appendString(body, opts.username)
+	}
+	if opts.password != "" {
+		body = appendString(body, opts.password)
+	}
+	return frame(packetConnect<<4, body)
+}
+
+func encodePublish(protocol byte, p packet) []byte {
+	var body []byte
+	body = appendString(body, p.topic)
+	if p.qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, p.packetID)
+	}
+	if protocol == ProtocolV5 {
+		body = appendVarInt(body, 0)
+	}
+	body = append(body, p.payload...)
+	header := byte(packetPublish<<4) | p.qos<<1
+	if p.retain {
+		header |= 0x01
+	}
+	if p.dup {
+		header |= 0x08
+	}
+	return frame(header, body)
+}
+
+func encodeAck(kind byte, packetID uint16) []byte {
+	header := kind << 4
+	if kind == packetPubrel {
+		header |= 0x02
+	}
+	return frame(header, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+func encodeSubscribe(protocol byte, packetID uint16, topic string, qos byte) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	if protocol == ProtocolV5 {
+		body = appendVarInt(body, 0)
+	}
+	body = appendString(body, topic)
+	body = append(body, qos&0x03)
+	return frame(packetSubscribe<<4|0x02, body)
+}
+
+func encodeUnsubscribe(protocol byte, packetID uint16, topic string) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	if protocol == ProtocolV5 {
+		body = appendVarInt(body, 0)
+	}
+	body = appendString(body, topic)
+	return frame(packetUnsubscribe<<4|0x02, body)
+}
+
+func encodePingreq() []byte {
+	return frame(packetPingreq<<4, nil)
+}
+
+func encodeDisconnect() []byte {
+	return frame(packetDisconnect<<4, nil)
+}
+
+func frame(header byte, body []byte) []byte {
+	out := make([]byte, 0, len(body)+5)
+	out = append(out, header)
+	out = appendVarInt(out, len(body))
+	return append(out, body...)
+}
+
+// readPacket reads one packet, refusing any whose remaining length is over
+// maxSize rather than allocating for it.
+func readPacket(r *bufio.Reader, protocol byte, maxSize int) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, err := readVarInt(r)
+	if err != nil {
+		return packet{}, err
+	}
+	if length > maxSize {
+		return packet{}, fmt.Errorf("mqtt packet of %d bytes exceeds the %d byte limit", length, maxSize)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	p := packet{kind: header >> 4, flags: header & 0x0f}
+	d := decoder{buf: body}
+	switch p.kind {
+	case packetConnack:
+		_ = d.byte()
+		p.reason = d.byte()
+	case packetPublish:
+		p.qos = (p.flags >> 1) & 0x03
+		p.retain = p.flags&0x01 != 0
+		p.dup = p.flags&0x08 != 0
+		p.topic = d.string()
+		if p.qos > 0 {
+			p.packetID = d.uint16()
+		}
+		if protocol == ProtocolV5 {
+			d.skipProperties()
+		}
+		p.payload = d.rest()
+	case packetPuback, packetPubrec, packetPubrel, packetPubcomp:
+		p.packetID = d.uint16()
+		if d.remaining() > 0 {
+			p.reason = d.byte()
+		}
+	case packetSuback, packetUnsuback:
+		p.packetID = d.uint16()
+		if protocol == ProtocolV5 {
+			d.skipProperties()
+		}
+		p.reasons = d.rest()
+	case packetPingresp, packetDisconnect:
+	default:
+		return p, fmt.Errorf("unexpected mqtt packet type %d", p.kind)
+	}
+	if d.err != nil {
+		return p, d.err
+	}
+	return p, nil
+}
+
+type decoder struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (d *decoder) remaining() int {
+	return len(d.buf) - d.pos
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || d.remaining() < n {
+		d.err = errors.New("mqtt packet truncated")
+		return nil
+	}
+	out := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return out
+}
+
+func (d *decoder) byte() byte {
+	b := d.take(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (d *decoder) uint16() uint16 {
+	b := d.take(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (d *decoder) string() string {
+	n := int(d.uint16())
+	return string(d.take(n))
+}
+
+func (d *decoder) varInt() int {
+	value, multiplier := 0, 1
+	for i := 0; i < 4; i++ {
+		b := d.byte()
+		if d.err != nil {
+			return 0
+		}
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			return value
+		}
+		multiplier *= 128
+	}
+	d.err = errors.New("mqtt variable length integer too long")
+	return 0
+}
+
+func (d *decoder) skipProperties() {
+	d.take(d.varInt())
+}
+
+func (d *decoder) rest() []byte {
+	if d.err != nil {
+		return nil
+	}
+	out := append([]byte(nil), d.buf[d.pos:]...)
+	d.pos = len(d.buf)
+	return out
+}
+
+func appendString(buf []byte, value string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(value)))
+	return append(buf, value...)
+}
+
+func appendVarInt(buf []byte, value int) []byte {
+	for {
+		b := byte(value % 128)
+		value /= 128
+		if value > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if value == 0 {
+			return buf
+		}
+	}
+}
+
+func readVarInt(r io.ByteReader) (int, error) {
+	value, multiplier := 0, 1
+	for i := 0; i < 4; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			if value > maxRemainingLength {
+				return 0, errors.New("mqtt remaining length too large")
+			}
+			return value, nil
+		}
+		multiplier *= 128
+	}
+	return 0, errors.New("mqtt remaining length malformed")
+}
+
+// topicMatches reports whether a topic name matches a subscription filter
+// using the + and # wildcards.
+func topicMatches(filter, topic string) bool {
+	fi, ti := 0, 0
+	for fi < len(filter) {
+		fEnd := indexByteFrom(filter, '/', fi)
+		level := filter[fi:fEnd]
+		if level == "#" {
+			return true
+		}
+		if ti > len(topic) {
+			return false
+		}
+		tEnd := indexByteFrom(topic, '/', ti)
+		if level != "+" && level != topic[ti:tEnd] {
+			return false
+		}
+		fi, ti = fEnd+1, tEnd+1
+	}
+	return ti > len(topic)
+}
+
+func indexByteFrom(s string, b byte, from int) int {
+	for i := from; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return len(s)
+}
+
@@ -0,0 +1,196 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// ParsePacket decodes an OSC 1.0 packet, flattening bundles into their
+// messages. Time tags are ignored; messages are handled on arrival.
+func ParsePacket(data []byte) ([]Message, error) {
+	if bytes.HasPrefix(data, []byte("#bundle\x00")) {
+		return parseBundle(data)
+	}
+	msg, err := parseMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	return []Message{msg}, nil
+}
+
+func parseBundle(data []byte) ([]Message, error) {
+	if len(data) < 16 {
+		return nil, errors.New("osc bundle truncated")
+	}
+	rest := data[16:]
+	var out []Message
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, errors.New("osc bundle element truncated")
+		}
+		size := int(binary.BigEndian.Uint32(rest))
+		rest = rest[4:]
+		if size < 0 || size > len(rest) {
+			return nil, errors.New("osc bundle element size out of range")
+		}
+		messages, err := ParsePacket(rest[:size])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, messages...)
+		rest = rest[size:]
+	}
+	return out, nil
+}
+
+func parseMessage(data []byte) (Message, error) {
+	address, rest, err := readString(data)
+	if err != nil {
+		return Message{}, err
+	}
+	if address == "" || address[0] != '/' {
+		return Message{}, fmt.Errorf("osc address %q invalid", address)
+	}
+	msg := Message{Address: address}
+	// Type tags are optional in OSC 1.0; osc-js always sends them, even
+	// for messages without arguments.
+	if len(rest) == 0 {
+		return msg, nil
+	}
+	tags, rest, err := readString(rest)
+	if err != nil {
+		return Message{}, err
+	}
+	if tags == "" || tags[0] != ',' {
+		return Message{}, errors.New("osc type tags missing")
+	}
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i':
+			if len(rest) < 4 {
+				return Message{}, errors.New("osc int argument truncated")
+			}
+			msg.Args = append(msg.Args, int32(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 'f':
+			if len(rest) < 4 {
+				return Message{}, errors.New("osc float argument truncated")
+			}
+			msg.Args = append(msg.Args, math.Float32frombits(binary.BigEndian.Uint32(rest)))
+			rest = rest[4:]
+		case 's':
+			var value string
+			value, rest, err = readString(rest)
+			if err != nil {
+				return Message{}, err
+			}
+			msg.Args = append(msg.Args, value)
+		case 'b':
+			if len(rest) < 4 {
+				return Message{}, errors.New("osc blob argument truncated")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			padded := 4 + (size+3)&^3
+			if size < 0 || padded > len(rest) {
+				return Message{}, errors.New("osc blob argument truncated")
+			}
+			msg.Args = append(msg.Args, append([]byte(nil), rest[4:4+size]...))
+			rest = rest[padded:]
+		case 'T':
+			msg.Args = append(msg.Args, true)
+		case 'F':
+			msg.Args = append(msg.Args, false)
+		case 'N', 'I':
+			msg.Args = append(msg.Args, nil)
+		default:
+			return Message{}, fmt.Errorf("osc type tag %q unsupported", tag)
+		}
+	}
+	return msg, nil
+}
+
+// EncodeMessage builds an OSC message. Whole numbers are sent as int32,
+// other numbers as float32.
+func EncodeMessage(address string, args ...any) ([]byte, error) {
+	if address == "" || address[0] != '/' {
+		return nil, fmt.Errorf("osc address %q invalid", address)
+	}
+	tags := []byte{','}
+	var body []byte
+	for _, arg := range args {
+		switch value := arg.(type) {
+		case nil:
+			tags = append(tags, 'N')
+		case bool:
+			if value {
+				tags = append(tags, 'T')
+			} else {
+				tags = append(tags, 'F')
+			}
+		case string:
+			tags = append(tags, 's')
+			body = appendString(body, value)
+		case int:
+			tags = append(tags, 'i')
+			body = binary.BigEndian.AppendUint32(body, uint32(int32(value)))
+		case int32:
+			tags = append(tags, 'i')
+			body = binary.BigEndian.AppendUint32(body, uint32(value))
+		case float32:
+			tags = append(tags, 'f')
+			body = binary.BigEndian.AppendUint32(body, math.Float32bits(value))
+		case float64:
+			if value == math.Trunc(value) && value >= math.MinInt32 && value <= math.MaxInt32 {
+				tags = append(tags, 'i')
+				body = binary.BigEndian.AppendUint32(body, uint32(int32(value)))
+			} else {
+				tags = append(tags, 'f')
+				body = binary.BigEndian.AppendUint32(body, math.Float32bits(float32(value)))
+			}
+		case []byte:
+			tags = append(tags, 'b')
+			body = binary.BigEndian.AppendUint32(body, uint32(len(value)))
+			body = append(body, value...)
+			for len(body)%4 != 0 {
+				body = append(body, 0)
+			}
+		default:
+			return nil, fmt.Errorf("osc argument type %T unsupported", arg)
+		}
+	}
+	out := appendString(nil, address)
+	out = appendString(out, string(tags))
+	return append(out, body...), nil
+}
+
+func appendString(buf []byte, value string) []byte {
+	buf = append(buf, value...)
+	buf = append(buf, 0)
+	for len(buf)%4 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// readString reads a null-terminated string padded to a multiple of four
+// bytes.
+func readString(data []byte) (string, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", nil, errors.New("osc string not terminated")
+	}
+	padded := (end + 4) &^ 3
+	if padded > len(data) {
+		padded = len(data)
+	}
+	return string(data[:end]), data[padded:], nil
+}
+
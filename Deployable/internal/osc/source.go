@@ -0,0 +1,159 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultListenAddr       = ":57121"
+	DefaultHeartbeatTimeout = 15 * time.Second
+
+	heartbeatAddress = "/meander/heartbeat"
+)
+
+// StateSource listens for the conductor's OSC broadcasts: /scene/{id} and
+// /fork/{id} on every state change and /meander/heartbeat every few
+// seconds.
+type StateSource struct {
+	Addr             string
+	HeartbeatTimeout time.Duration
+	// OnState is called with "scene" or "fork" and the state ID.
+	OnState func(kind, id string)
+	// OnHeartbeat is called when heartbeats stop arriving within
+	// HeartbeatTimeout and again when they resume.
+	OnHeartbeat func(alive bool)
+
+	mu            sync.Mutex
+	listening     string
+	alive         bool
+	lastHeartbeat time.Time
+	lastMessage   string
+	lastMessageAt time.Time
+	packets       int
+}
+
+func (s *StateSource) Run(ctx context.Context) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultListenAddr
+	}
+	timeout := s.HeartbeatTimeout
+	if timeout <= 0 {
+		timeout = DefaultHeartbeatTimeout
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listening = conn.LocalAddr().String()
+	s.alive = true
+	s.lastHeartbeat = time.Now()
+	s.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+	go s.watchHeartbeat(ctx, timeout)
+
+	buf := make([]byte, 65536)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		messages, err := ParsePacket(buf[:n])
+		if err != nil {
+			log.Printf("osc: ignoring packet from %s: %v", from, err)
+			continue
+		}
+		for _, msg := range messages {
+			s.handle(msg)
+		}
+	}
+}
+
+func (s *StateSource) handle(msg Message) {
+	now := time.Now()
+	s.mu.Lock()
+	s.packets++
+	if msg.Address == heartbeatAddress {
+		s.lastHeartbeat = now
+		restored := !s.alive
+		s.alive = true
+		s.mu.Unlock()
+		if restored {
+			log.Printf("osc: conductor heartbeat restored")
+			if s.OnHeartbeat != nil {
+				s.OnHeartbeat(true)
+			}
+		}
+		return
+	}
+	s.lastMessage = msg.Address
+	s.lastMessageAt = now
+	s.mu.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(msg.Address, "/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return
+	}
+	switch parts[0] {
+	case "scene", "fork":
+		if s.OnState != nil {
+			s.OnState(parts[0], parts[1])
+		}
+	}
+}
+
+func (s *StateSource) watchHeartbeat(ctx context.Context, timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			lost := s.alive && now.Sub(s.lastHeartbeat) > timeout
+			if lost {
+				s.alive = false
+			}
+			s.mu.Unlock()
+			if lost {
+				log.Printf("osc: no conductor heartbeat for %s", timeout)
+				if s.OnHeartbeat != nil {
+					s.OnHeartbeat(false)
+				}
+			}
+		}
+	}
+}
+
+func (s *StateSource) Snapshot() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := map[string]any{
+		"listen":          s.listening,
+		"heartbeat_alive": s.alive,
+		"packets":         s.packets,
+		"last_message":    s.lastMessage,
+	}
+	if !s.lastHeartbeat.IsZero() {
+		snapshot["last_heartbeat"] = s.lastHeartbeat.UTC()
+	}
+	if !s.lastMessageAt.IsZero() {
+		snapshot["last_message_at"] = s.lastMessageAt.UTC()
+	}
+	return snapshot
+}
+
@@ -0,0 +1,715 @@
+package playback
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	mpvReplyTimeout = 2 * time.Second
+	mpvEventQueue   = 16
+)
+
+// MPVBackend drives mpv through its JSON IPC socket. Like the VLC command
+// backend it keeps one idle mpv per output and loads media into it, so
+// video outputs stay fullscreen between media. Seeks are exact to the
+// millisecond. A crashed mpv is reported and started again on the next
+// play. A crossfade starts a second mpv for the output's other deck and
+// keeps whichever deck is fading in on top.
+type MPVBackend struct {
+	MPVPath string
+	// AudioDevices maps output IDs to mpv audio devices, as listed by
+	// mpv --audio-device=help.
+	AudioDevices map[string]string
+
+	mu      sync.Mutex
+	players map[string]*mpvPlayer
+	events  func(Event)
+}
+
+func NewMPVBackend(path string) *MPVBackend {
+	if strings.TrimSpace(path) == "" {
+		path = "mpv"
+	}
+	return &MPVBackend{MPVPath: path, players: make(map[string]*mpvPlayer)}
+}
+
+func (b *MPVBackend) SetEventHandler(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = fn
+}
+
+func (b *MPVBackend) Open(assetPath string, output OutputDevice) (BackendInstance, error) {
+	return b.OpenDeck(assetPath, output, 0)
+}
+
+func (b *MPVBackend) OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error) {
+	key := deckKey(output.ID, deck)
+	b.mu.Lock()
+	if b.players == nil {
+		b.players = make(map[string]*mpvPlayer)
+	}
+	player, ok := b.players[key]
+	if !ok {
+		player = &mpvPlayer{backend: b, output: output, key: key, deck: deck}
+		b.players[key] = player
+	}
+	b.mu.Unlock()
+	gen, err := player.claim()
+	if err != nil {
+		return nil, err
+	}
+	return &MPVInstance{player: player, gen: gen, assetPath: assetPath}, nil
+}
+
+func (b *MPVBackend) OutputStatus(outputID string) map[string]any {
+	b.mu.Lock()
+	player, ok := b.players[outputID]
+	second := b.players[deckKey(outputID, 1)]
+	b.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	status := player.status()
+	if second != nil {
+		status["deck_b"] = second.status()
+	}
+	return status
+}
+
+// Close quits every mpv started by the backend.
+func (b *MPVBackend) Close() error {
+	b.mu.Lock()
+	players := b.players
+	b.players = make(map[string]*mpvPlayer)
+	b.mu.Unlock()
+	for _, player := range players {
+		player.close()
+	}
+	return nil
+}
+
+func (b *MPVBackend) emit(event Event) {
+	event.Timestamp = time.Now().UTC()
+	if event.Error != "" {
+		log.Printf("mpv %s: %s: %s", event.OutputID, event.Type, event.Error)
+	} else {
+		log.Printf("mpv %s: %s", event.OutputID, event.Type)
+	}
+	b.mu.Lock()
+	fn := b.events
+	b.mu.Unlock()
+	if fn != nil {
+		fn(event)
+	}
+}
+
+func (b *MPVBackend) start(output OutputDevice, key string) (*mpvProcess, error) {
+	socket := mpvIPCPath(key)
+	_ = os.Remove(socket)
+	args := []string{
+		"--idle=yes",
+		"--no-terminal",
+		"--input-ipc-server=" + socket,
+		"--no-input-default-bindings",
+		"--no-osc",
+		"--osd-level=0",
+		"--hr-seek=yes",
+	}
+	if output.Type == "video" {
+		args = append(args, "--force-window=yes", "--fullscreen")
+		if idx, ok := parseDisplayIndex(output.ID); ok {
+			args = append(args, "--screen="+strconv.Itoa(idx), "--fs-screen="+strconv.Itoa(idx))
+		}
+	} else {
+		args = append(args, "--no-video", "--force-window=no")
+	}
+	b.mu.Lock()
+	device := b.AudioDevices[output.ID]
+	b.mu.Unlock()
+	if device != "" {
+		args = append(args, "--audio-device="+device)
+	}
+
+	cmd := exec.Command(b.MPVPath, args...)
+	hideWindow(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	proc := &mpvProcess{
+		cmd:          cmd,
+		socket:       socket,
+		exited:       make(chan struct{}),
+		disconnected: make(chan struct{}),
+		replies:      make(map[int64]chan mpvMessage),
+	}
+	go func() {
+		proc.waitErr = cmd.Wait()
+		close(proc.exited)
+	}()
+	conn, err := connectMPV(socket, proc.exited)
+	if err != nil {
+		proc.kill()
+		return nil, err
+	}
+	proc.conn = conn
+	return proc, nil
+}
+
+func connectMPV(socket string, exited <-chan struct{}) (net.Conn, error) {
+	var lastErr error
+	for i := 0; i < 50; i++ {
+		conn, err := dialMPV(socket)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		select {
+		case <-exited:
+			return nil, errors.New("mpv exited before its ipc socket became available")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return nil, lastErr
+}
+
+// mpvMessage is any line mpv writes: a reply carries request_id and error,
+// an event carries event and its fields.
+type mpvMessage struct {
+	RequestID int64           `json:"request_id"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	Event     string          `json:"event"`
+	Name      string          `json:"name"`
+	Reason    string          `json:"reason"`
+	FileError string          `json:"file_error"`
+}
+
+type mpvProcess struct {
+	cmd          *exec.Cmd
+	socket       string
+	conn         net.Conn
+	exited       chan struct{}
+	disconnected chan struct{}
+	waitErr      error
+
+	writeMu sync.Mutex
+
+	// Guarded by mu: outstanding requests and observed properties.
+	mu       sync.Mutex
+	nextID   int64
+	replies  map[int64]chan mpvMessage
+	asset    string
+	timePos  float64
+	duration float64
+	paused   bool
+	idle     bool
+}
+
+// command sends an IPC command and waits for its reply.
+func (p *mpvProcess) command(args ...any) (json.RawMessage, error) {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	reply := make(chan mpvMessage, 1)
+	p.replies[id] = reply
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.replies, id)
+		p.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(map[string]any{"command": args, "request_id": id})
+	if err != nil {
+		return nil, err
+	}
+	p.writeMu.Lock()
+	_ = p.conn.SetWriteDeadline(time.Now().Add(mpvReplyTimeout))
+	_, err = p.conn.Write(append(data, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		_ = p.conn.Close()
+		return nil, err
+	}
+	select {
+	case msg := <-reply:
+		if msg.Error != "success" {
+			return nil, fmt.Errorf("mpv %v: %s", args[0], msg.Error)
+		}
+		return msg.Data, nil
+	case <-p.disconnected:
+		return nil, errors.New("mpv ipc connection lost")
+	case <-time.After(mpvReplyTimeout):
+		return nil, fmt.Errorf("mpv %v: no reply", args[0])
+	}
+}
+
+func (p *mpvProcess) setProperty(name string, value any) error {
+	_, err := p.command("set_property", name, value)
+	return err
+}
+
+func (p *mpvProcess) observe() error {
+	for i, name := range []string{"time-pos", "duration", "pause", "idle-active"} {
+		if _, err := p.command("observe_property", i+1, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read dispatches replies and property changes until the connection ends.
+// It never takes the player lock or waits on event handlers, since
+// commands wait for their replies while holding the lock and handlers may
+// send commands; onEnd must not block.
+func (p *mpvProcess) read(onEnd func(asset, reason, fileError string)) {
+	defer close(p.disconnected)
+	reader := bufio.NewReader(p.conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var msg mpvMessage
+			if json.Unmarshal(line, &msg) == nil {
+				p.handle(msg, onEnd)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *mpvProcess) handle(msg mpvMessage, onEnd func(asset, reason, fileError string)) {
+	p.mu.Lock()
+	switch msg.Event {
+	case "":
+		reply, ok := p.replies[msg.RequestID]
+		p.mu.Unlock()
+		if ok {
+			reply <- msg
+		}
+		return
+	case "property-change":
+		switch msg.Name {
+		case "time-pos":
+			p.timePos = decodeFloat(msg.Data)
+		case "duration":
+			p.duration = decodeFloat(msg.Data)
+		case "pause":
+			_ = json.Unmarshal(msg.Data, &p.paused)
+		case "idle-active":
+			_ = json.Unmarshal(msg.Data, &p.idle)
+		}
+	case "end-file":
+		asset := p.asset
+		p.mu.Unlock()
+		// Replacing or stopping media also ends a file; only natural
+		// ends and failures are reported.
+		if msg.Reason == "eof" || msg.Reason == "error" {
+			onEnd(asset, msg.Reason, msg.FileError)
+		}
+		return
+	}
+	p.mu.Unlock()
+}
+
+func decodeFloat(data json.RawMessage) float64 {
+	var value float64
+	if json.Unmarshal(data, &value) != nil {
+		return 0
+	}
+	return value
+}
+
+func (p *mpvProcess) setAsset(asset string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.asset = asset
+	p.timePos, p.duration = 0, 0
+}
+
+func (p *mpvProcess) mediaStatus() MediaStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return MediaStatus{
+		Playing:    !p.idle && !p.paused,
+		PositionMs: int(p.timePos * 1000),
+		DurationMs: int(p.duration * 1000),
+	}
+}
+
+func (p *mpvProcess) kill() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	select {
+	case <-p.exited:
+	default:
+		_ = p.cmd.Process.Kill()
+	}
+	_ = os.Remove(p.socket)
+}
+
+// mpvPlayer is the mpv process bound to an output. As with VLC, only the
+// instance holding the current generation may control it.
+type mpvPlayer struct {
+	backend *MPVBackend
+	output  OutputDevice
+	key     string
+	deck    int
+
+	// cmdMu orders IPC commands, so a replaced instance's command cannot
+	// land after its successor's. mu is held only briefly, never across a
+	// start or a reply, so status stays responsive.
+	cmdMu      sync.Mutex
+	mu         sync.Mutex
+	proc       *mpvProcess
+	current    uint64
+	closed     bool
+	starting   chan struct{}
+	starts     int
+	lastExit   string
+	lastExitAt time.Time
+}
+
+func (p *mpvPlayer) claim() (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.ensureLocked(); err != nil {
+		return 0, err
+	}
+	p.current++
+	return p.current, nil
+}
+
+// ensureLocked starts mpv unless it is running, waiting for a start that
+// is already under way. p.mu is released while mpv starts.
+func (p *mpvPlayer) ensureLocked() error {
+	for p.starting != nil {
+		starting := p.starting
+		p.mu.Unlock()
+		<-starting
+		p.mu.Lock()
+	}
+	if p.closed {
+		return errors.New("mpv backend closed")
+	}
+	if p.proc != nil {
+		return nil
+	}
+	starting := make(chan struct{})
+	p.starting = starting
+	p.mu.Unlock()
+	proc, err := p.launch()
+	p.mu.Lock()
+	p.starting = nil
+	close(starting)
+	if err != nil {
+		return err
+	}
+	if p.closed {
+		proc.kill()
+		return errors.New("mpv backend closed")
+	}
+	p.proc = proc
+	p.starts++
+	go p.supervise(proc)
+	return nil
+}
+
+// launch starts mpv, connects to its IPC socket and observes the
+// properties status reads.
+func (p *mpvPlayer) launch() (*mpvProcess, error) {
+	proc, err := p.backend.start(p.output, p.key)
+	if err != nil {
+		return nil, err
+	}
+	// Media end events are emitted from their own goroutine, so the read
+	// loop keeps delivering replies to commands a handler sends.
+	events := make(chan Event, mpvEventQueue)
+	go func() {
+		for event := range events {
+			p.backend.emit(event)
+		}
+	}()
+	go func() {
+		defer close(events)
+		proc.read(func(asset, reason, fileError string) {
+			event := Event{OutputID: p.output.ID, Type: EventMediaEnded, Asset: asset}
+			if reason == "error" {
+				event.Type, event.Error = EventMediaError, fileError
+			}
+			select {
+			case events <- event:
+			default:
+				log.Printf("mpv %s: event queue full, dropped %s", p.output.ID, event.Type)
+			}
+		})
+	}()
+	if err := proc.observe(); err != nil {
+		proc.kill()
+		return nil, err
+	}
+	return proc, nil
+}
+
+func (p *mpvPlayer) supervise(proc *mpvProcess) {
+	select {
+	case <-proc.exited:
+	case <-proc.disconnected:
+		select {
+		case <-proc.exited:
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	reason := "mpv ipc connection lost"
+	select {
+	case <-proc.exited:
+		reason = "mpv exited"
+		if proc.waitErr != nil {
+			reason += ": " + proc.waitErr.Error()
+		}
+	default:
+	}
+	p.mu.Lock()
+	if p.closed || p.proc != proc {
+		p.mu.Unlock()
+		return
+	}
+	p.proc = nil
+	proc.kill()
+	p.lastExit, p.lastExitAt = reason, time.Now()
+	p.mu.Unlock()
+	p.backend.emit(Event{OutputID: p.output.ID, Type: EventPlayerExited, Error: reason})
+}
+
+// do runs fn against the process for generation gen; calls from replaced
+// instances are ignored.
+func (p *mpvPlayer) do(gen uint64, fn func(proc *mpvProcess) error) error {
+	p.cmdMu.Lock()
+	defer p.cmdMu.Unlock()
+	p.mu.Lock()
+	if gen != p.current {
+		p.mu.Unlock()
+		return nil
+	}
+	err := p.ensureLocked()
+	proc := p.proc
+	if err == nil && gen != p.current {
+		// Claimed by a newer instance while mpv was starting.
+		proc = nil
+	}
+	p.mu.Unlock()
+	if err != nil || proc == nil {
+		return err
+	}
+	return fn(proc)
+}
+
+// setOntop keeps a running mpv above or below the other deck. It does not
+// start mpv.
+func (p *mpvPlayer) setOntop(ontop bool) {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+	if proc != nil {
+		_ = proc.setProperty("ontop", ontop)
+	}
+}
+
+func (p *mpvPlayer) mediaStatus(gen uint64) (MediaStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if gen != p.current || p.proc == nil {
+		return MediaStatus{}, false
+	}
+	return p.proc.mediaStatus(), true
+}
+
+func (p *mpvPlayer) status() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := map[string]any{
+		"running": p.proc != nil,
+		"starts":  p.starts,
+	}
+	if p.proc != nil && p.proc.cmd.Process != nil {
+		status["pid"] = p.proc.cmd.Process.Pid
+		status["socket"] = p.proc.socket
+	}
+	if p.lastExit != "" {
+		status["last_exit"] = p.lastExit
+		status["last_exit_at"] = p.lastExitAt.UTC()
+	}
+	return status
+}
+
+func (p *mpvPlayer) close() {
+	p.mu.Lock()
+	p.closed = true
+	proc := p.proc
+	p.proc = nil
+	p.mu.Unlock()
+	if proc == nil {
+		return
+	}
+	_, _ = proc.command("quit")
+	select {
+	case <-proc.exited:
+	case <-time.After(2 * time.Second):
+	}
+	proc.kill()
+}
+
+type MPVInstance struct {
+	player    *mpvPlayer
+	gen       uint64
+	assetPath string
+
+	mu      sync.Mutex
+	started bool
+	startMs int
+}
+
+// Play loads the media into the output's mpv, replacing what it was
+// playing. A seek requested before Play becomes the start position.
+func (m *MPVInstance) Play() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return m.player.do(m.gen, func(proc *mpvProcess) error {
+			return proc.setProperty("pause", false)
+		})
+	}
+	if err := m.load(false); err != nil {
+		return err
+	}
+	m.started = true
+	return nil
+}
+
+func (m *MPVInstance) load(paused bool) error {
+	start := "none"
+	if m.startMs > 0 {
+		start = formatSeconds(m.startMs)
+	}
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		if err := proc.setProperty("start", start); err != nil {
+			return err
+		}
+		if err := proc.setProperty("pause", paused); err != nil {
+			return err
+		}
+		proc.setAsset(m.assetPath)
+		_, err := proc.command("loadfile", m.assetPath, "replace")
+		return err
+	})
+}
+
+// Prepare loads the media paused, so mpv decodes the first frame and
+// buffers audio before Play.
+func (m *MPVInstance) Prepare() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(true); err != nil {
+		return err
+	}
+	m.started = true
+	return nil
+}
+
+func (m *MPVInstance) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = false
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		proc.setAsset("")
+		_, err := proc.command("stop")
+		return err
+	})
+}
+
+func (m *MPVInstance) Pause() error {
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		return proc.setProperty("pause", true)
+	})
+}
+
+func (m *MPVInstance) Resume() error {
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		return proc.setProperty("pause", false)
+	})
+}
+
+func (m *MPVInstance) Seek(ms int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.started {
+		m.startMs = ms
+		return nil
+	}
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		_, err := proc.command("seek", float64(ms)/1000, "absolute+exact")
+		return err
+	})
+}
+
+func (m *MPVInstance) SetVolume(vol float64) error {
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		return proc.setProperty("volume", clampVolume(vol)*100)
+	})
+}
+
+func (m *MPVInstance) SetLoop(loop bool) error {
+	value := "no"
+	if loop {
+		value = "inf"
+	}
+	return m.player.do(m.gen, func(proc *mpvProcess) error {
+		return proc.setProperty("loop-file", value)
+	})
+}
+
+// Raise puts this deck's window above the other deck of the output, so
+// the media fading in is the one on screen.
+func (m *MPVInstance) Raise() error {
+	player := m.player
+	player.backend.mu.Lock()
+	other := player.backend.players[deckKey(player.output.ID, 1-player.deck)]
+	player.backend.mu.Unlock()
+	if err := player.do(m.gen, func(proc *mpvProcess) error {
+		return proc.setProperty("ontop", true)
+	}); err != nil {
+		return err
+	}
+	if other != nil {
+		other.setOntop(false)
+	}
+	return nil
+}
+
+func (m *MPVInstance) Status() (MediaStatus, bool) {
+	return m.player.mediaStatus(m.gen)
+}
+
+// Close leaves mpv running for the next media.
+func (m *MPVInstance) Close() error {
+	return nil
+}
+
+func formatSeconds(ms int) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', 3, 64)
+}
+
@@ -19,6 +19,11 @@ func (b *StubBackend) Open(assetPath string, output OutputDevice) (BackendInstan
 	}, nil
 }
 
+// OpenDeck opens independent instances, so both decks can play at once.
+func (b *StubBackend) OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error) {
+	return b.Open(assetPath, output)
+}
+
 type StubInstance struct {
 	mu        sync.Mutex
 	assetPath string
@@ -0,0 +1,381 @@
+package playback
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TimelineEntry is one recorded backend call. Instance numbers the Open
+// that created the instance, so calls on a replaced instance can be told
+// apart from calls on its successor. Deck tells the two crossfade decks of
+// an output apart.
+type TimelineEntry struct {
+	At       time.Time `json:"at"`
+	OutputID string    `json:"output_id"`
+	Instance int       `json:"instance"`
+	Deck     int       `json:"deck,omitempty"`
+	Call     string    `json:"call"`
+	Asset    string    `json:"asset,omitempty"`
+	Value    any       `json:"value,omitempty"`
+}
+
+// TimelineBackend plays nothing. It records every call into a timeline
+// that tests and rehearsals can inspect, and simulates media durations so
+// end-of-media events fire as they would with real media.
+type TimelineBackend struct {
+	// Durations maps asset paths or file names to simulated durations.
+	Durations map[string]time.Duration
+	// DefaultDuration applies to assets missing from Durations; zero
+	// means such media never ends.
+	DefaultDuration time.Duration
+	// MaxEntries bounds the in-memory timeline; the oldest entries are
+	// dropped beyond it. Zero keeps every entry. The timeline file is not
+	// affected.
+	MaxEntries int
+
+	mu        sync.Mutex
+	entries   []TimelineEntry
+	instances int
+	file      *os.File
+	encoder   *json.Encoder
+	events    func(Event)
+}
+
+// DefaultTimelineEntries is the in-memory timeline size of a new backend.
+const DefaultTimelineEntries = 10000
+
+func NewTimelineBackend() *TimelineBackend {
+	return &TimelineBackend{
+		Durations:  make(map[string]time.Duration),
+		MaxEntries: DefaultTimelineEntries,
+	}
+}
+
+// RecordTo also writes each entry to path as a JSON line, replacing any
+// earlier file.
+func (b *TimelineBackend) RecordTo(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file != nil {
+		_ = b.file.Close()
+	}
+	b.file = file
+	b.encoder = json.NewEncoder(file)
+	return nil
+}
+
+func (b *TimelineBackend) SetEventHandler(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = fn
+}
+
+// Timeline returns a copy of the entries recorded so far.
+func (b *TimelineBackend) Timeline() []TimelineEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]TimelineEntry(nil), b.retainedLocked()...)
+}
+
+// Reset clears the in-memory timeline.
+func (b *TimelineBackend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+}
+
+func (b *TimelineBackend) Open(assetPath string, output OutputDevice) (BackendInstance, error) {
+	return b.OpenDeck(assetPath, output, 0)
+}
+
+func (b *TimelineBackend) OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error) {
+	b.mu.Lock()
+	b.instances++
+	instance := &TimelineInstance{
+		backend:  b,
+		id:       b.instances,
+		deck:     deck,
+		output:   output,
+		asset:    assetPath,
+		duration: b.durationLocked(assetPath),
+		volume:   1.0,
+	}
+	b.mu.Unlock()
+	instance.record("open", nil)
+	return instance, nil
+}
+
+func (b *TimelineBackend) OutputStatus(outputID string) map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calls := 0
+	for _, entry := range b.retainedLocked() {
+		if entry.OutputID == outputID {
+			calls++
+		}
+	}
+	return map[string]any{"recorded_calls": calls}
+}
+
+// Close stops writing the timeline file; the in-memory timeline remains.
+func (b *TimelineBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file, b.encoder = nil, nil
+	return err
+}
+
+func (b *TimelineBackend) durationLocked(assetPath string) time.Duration {
+	if duration, ok := b.Durations[assetPath]; ok {
+		return duration
+	}
+	if duration, ok := b.Durations[filepath.Base(assetPath)]; ok {
+		return duration
+	}
+	return b.DefaultDuration
+}
+
+func (b *TimelineBackend) record(entry TimelineEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, entry)
+	// Trimming once the slice holds twice the limit keeps appends cheap;
+	// readers only see the newest MaxEntries.
+	if limit := b.MaxEntries; limit > 0 && len(b.entries) >= 2*limit {
+		b.entries = append(b.entries[:0], b.entries[len(b.entries)-limit:]...)
+	}
+	if b.encoder != nil {
+		if err := b.encoder.Encode(entry); err != nil {
+			log.Printf("timeline: write failed: %v", err)
+		}
+	}
+}
+
+func (b *TimelineBackend) retainedLocked() []TimelineEntry {
+	if limit := b.MaxEntries; limit > 0 && len(b.entries) > limit {
+		return b.entries[len(b.entries)-limit:]
+	}
+	return b.entries
+}
+
+func (b *TimelineBackend) emit(event Event) {
+	b.mu.Lock()
+	fn := b.events
+	b.mu.Unlock()
+	if fn != nil {
+		fn(event)
+	}
+}
+
+type TimelineInstance struct {
+	backend  *TimelineBackend
+	id       int
+	deck     int
+	output   OutputDevice
+	asset    string
+	duration time.Duration
+
+	mu         sync.Mutex
+	playing    bool
+	paused     bool
+	loop       bool
+	volume     float64
+	positionMs int
+	at         time.Time
+	timer      *time.Timer
+	// run invalidates timers that fire after being replaced.
+	run int
+}
+
+func (t *TimelineInstance) record(call string, value any) {
+	t.backend.record(TimelineEntry{
+		At:       time.Now().UTC(),
+		OutputID: t.output.ID,
+		Instance: t.id,
+		Deck:     t.deck,
+		Call:     call,
+		Asset:    t.asset,
+		Value:    value,
+	})
+}
+
+func (t *TimelineInstance) Play() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("play", nil)
+	if t.playing && !t.paused {
+		return nil
+	}
+	t.playing, t.paused, t.at = true, false, time.Now()
+	t.scheduleLocked()
+	return nil
+}
+
+// Prepare records the cue and holds the media paused at its start.
+func (t *TimelineInstance) Prepare() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("prepare", nil)
+	t.cancelLocked()
+	t.playing, t.paused = true, true
+	return nil
+}
+
+func (t *TimelineInstance) Stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("stop", nil)
+	t.playing, t.paused, t.positionMs = false, false, 0
+	t.cancelLocked()
+	return nil
+}
+
+func (t *TimelineInstance) Pause() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("pause", nil)
+	if !t.playing || t.paused {
+		return nil
+	}
+	t.positionMs, t.paused = t.positionLocked(time.Now()), true
+	t.cancelLocked()
+	return nil
+}
+
+func (t *TimelineInstance) Resume() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("resume", nil)
+	if !t.playing || !t.paused {
+		return nil
+	}
+	t.paused, t.at = false, time.Now()
+	t.scheduleLocked()
+	return nil
+}
+
+func (t *TimelineInstance) Seek(ms int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("seek", ms)
+	t.positionMs, t.at = ms, time.Now()
+	if t.playing && !t.paused {
+		t.scheduleLocked()
+	}
+	return nil
+}
+
+func (t *TimelineInstance) SetVolume(vol float64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("set_volume", vol)
+	t.volume = vol
+	return nil
+}
+
+func (t *TimelineInstance) SetLoop(loop bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("set_loop", loop)
+	t.loop = loop
+	return nil
+}
+
+func (t *TimelineInstance) SetOpacity(opacity float64) error {
+	t.record("set_opacity", opacity)
+	return nil
+}
+
+func (t *TimelineInstance) Status() (MediaStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return MediaStatus{
+		Playing:    t.playing && !t.paused,
+		PositionMs: t.positionLocked(time.Now()),
+		DurationMs: int(t.duration / time.Millisecond),
+	}, true
+}
+
+func (t *TimelineInstance) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.record("close", nil)
+	t.playing = false
+	t.cancelLocked()
+	return nil
+}
+
+func (t *TimelineInstance) positionLocked(now time.Time) int {
+	position := t.positionMs
+	if t.playing && !t.paused {
+		position += int(now.Sub(t.at) / time.Millisecond)
+	}
+	if limit := int(t.duration / time.Millisecond); limit > 0 && position > limit {
+		position = limit
+	}
+	return position
+}
+
+// scheduleLocked arms the end-of-media timer for the remaining duration.
+func (t *TimelineInstance) scheduleLocked() {
+	t.cancelLocked()
+	if t.duration <= 0 {
+		return
+	}
+	remaining := t.duration - time.Duration(t.positionLocked(time.Now()))*time.Millisecond
+	if remaining < 0 {
+		remaining = 0
+	}
+	run := t.run
+	t.timer = time.AfterFunc(remaining, func() { t.ended(run) })
+}
+
+func (t *TimelineInstance) cancelLocked() {
+	t.run++
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *TimelineInstance) ended(run int) {
+	t.mu.Lock()
+	if run != t.run || !t.playing || t.paused {
+		t.mu.Unlock()
+		return
+	}
+	if t.loop {
+		t.record("loop", nil)
+		t.positionMs, t.at = 0, time.Now()
+		t.scheduleLocked()
+		t.mu.Unlock()
+		return
+	}
+	t.record(EventMediaEnded, nil)
+	t.playing = false
+	t.positionMs = int(t.duration / time.Millisecond)
+	t.timer = nil
+	t.mu.Unlock()
+	t.backend.emit(Event{
+		OutputID:  t.output.ID,
+		Type:      EventMediaEnded,
+		Asset:     t.asset,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
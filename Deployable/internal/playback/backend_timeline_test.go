@@ -0,0 +1,227 @@
+package playback
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testOutput = "display-0"
+
+// newTimelineManager returns a manager over a timeline backend with one
+// video output and the named assets present in its assets directory.
+func newTimelineManager(t *testing.T, assets ...string) (*Manager, *TimelineBackend) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, asset := range assets {
+		if err := os.WriteFile(filepath.Join(dir, asset), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeline := NewTimelineBackend()
+	m := NewManager(dir, timeline)
+	m.ConfigureOutputDevices([]OutputDevice{{ID: testOutput, Name: "Display 0", Type: "video"}})
+	t.Cleanup(m.Close)
+	return m, timeline
+}
+
+// waitForCall waits until the timeline has a call on the given instance.
+func waitForCall(t *testing.T, timeline *TimelineBackend, instance int, call string) []TimelineEntry {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries := timeline.Timeline()
+		for _, entry := range entries {
+			if entry.Instance == instance && entry.Call == call {
+				return entries
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s on instance %d in %v", call, instance, calls(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func calls(entries []TimelineEntry) []string {
+	out := make([]string, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.Call
+	}
+	return out
+}
+
+func volumes(entries []TimelineEntry, instance int) []float64 {
+	var out []float64
+	for _, entry := range entries {
+		if entry.Instance == instance && entry.Call == "set_volume" {
+			out = append(out, entry.Value.(float64))
+		}
+	}
+	return out
+}
+
+func TestTimelinePlayEndsMedia(t *testing.T) {
+	m, timeline := newTimelineManager(t, "intro.mp4")
+	timeline.Durations["intro.mp4"] = 50 * time.Millisecond
+	ended := make(chan Event, 1)
+	m.SetEventHandler(func(event Event) {
+		if event.Type == EventMediaEnded {
+			ended <- event
+		}
+	})
+	if err := m.Play(testOutput, PlayRequest{AssetPath: "intro.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-ended:
+		if event.OutputID != testOutput {
+			t.Fatalf("media_ended for %s", event.OutputID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no media_ended")
+	}
+	entries := timeline.Timeline()
+	first := entries[0]
+	if first.Call != "open" || first.OutputID != testOutput || filepath.Base(first.Asset) != "intro.mp4" {
+		t.Fatalf("first entry %+v", first)
+	}
+	waitForCall(t, timeline, first.Instance, "play")
+	waitForCall(t, timeline, first.Instance, EventMediaEnded)
+}
+
+func TestTimelineCrossfadeSwitchesDecks(t *testing.T) {
+	m, timeline := newTimelineManager(t, "a.mp4", "b.mp4")
+	if err := m.Play(testOutput, PlayRequest{AssetPath: "a.mp4", Loop: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Play(testOutput, PlayRequest{AssetPath: "b.mp4", CrossfadeMs: 200}); err != nil {
+		t.Fatal(err)
+	}
+	entries := timeline.Timeline()
+	outgoing := entries[0].Instance
+	var incoming TimelineEntry
+	for _, entry := range entries {
+		if entry.Call == "open" && entry.Instance != outgoing {
+			incoming = entry
+		}
+	}
+	if incoming.Instance == 0 || incoming.Deck == entries[0].Deck {
+		t.Fatalf("crossfade did not open the other deck: %+v", entries)
+	}
+	entries = waitForCall(t, timeline, outgoing, "close")
+	down, up := volumes(entries, outgoing), volumes(entries, incoming.Instance)
+	if len(down) < 2 || len(up) < 2 {
+		t.Fatalf("no volume ramps: outgoing %v, incoming %v", down, up)
+	}
+	if up[0] != 0 || up[len(up)-1] != 1 {
+		t.Fatalf("incoming ramp %v does not go from 0 to 1", up)
+	}
+	for i := 1; i < len(down); i++ {
+		if down[i] > down[i-1] {
+			t.Fatalf("outgoing ramp %v rises", down)
+		}
+	}
+	for _, entry := range entries {
+		if entry.Instance == incoming.Instance && (entry.Call == "stop" || entry.Call == "close") {
+			t.Fatalf("incoming media was %s", entry.Call)
+		}
+	}
+}
+
+func TestTimelineFadeOutStops(t *testing.T) {
+	m, timeline := newTimelineManager(t, "a.mp4")
+	if err := m.Play(testOutput, PlayRequest{AssetPath: "a.mp4"}); err != nil {
+		t.Fatal(err)
+	}
+	instance := timeline.Timeline()[0].Instance
+	if err := m.FadeOut(testOutput, 150, FadeLinear); err != nil {
+		t.Fatal(err)
+	}
+	entries := waitForCall(t, timeline, instance, "stop")
+	steps := volumes(entries, instance)
+	if len(steps) < 2 || steps[len(steps)-1] != 0 {
+		t.Fatalf("fade-out steps %v do not end silent", steps)
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i] > steps[i-1] {
+			t.Fatalf("fade-out steps %v rise", steps)
+		}
+	}
+	if status := m.Status(); len(status) != 1 || status[0].Active {
+		t.Fatalf("output still active after fade-out: %+v", status)
+	}
+}
+
+func TestTimelineDropsOldestEntries(t *testing.T) {
+	timeline := NewTimelineBackend()
+	timeline.MaxEntries = 3
+	instance, _ := timeline.Open("a.mp4", OutputDevice{ID: testOutput})
+	for i := 0; i < 10; i++ {
+		_ = instance.Seek(i)
+	}
+	entries := timeline.Timeline()
+	if len(entries) != 3 {
+		t.Fatalf("kept %d entries, want 3", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Call != "seek" || entry.Value != 7+i {
+			t.Fatalf("entry %d is %+v, want seek %d", i, entry, 7+i)
+		}
+	}
+}
+
+func TestTimelinePlaySyncCrossfades(t *testing.T) {
+	m, timeline := newTimelineManager(t, "a.mp4", "b.mp4")
+	m.ConfigureOutputDevices([]OutputDevice{{ID: "display-1", Name: "Display 1", Type: "video"}})
+	outputs := []string{testOutput, "display-1"}
+	for _, outputID := range outputs {
+		if err := m.Play(outputID, PlayRequest{AssetPath: "a.mp4", Loop: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items := make([]SyncItem, len(outputs))
+	for i, outputID := range outputs {
+		items[i] = SyncItem{OutputID: outputID, Request: PlayRequest{AssetPath: "b.mp4", CrossfadeMs: 200}}
+	}
+	if err := m.PlaySync("wall", items); err != nil {
+		t.Fatal(err)
+	}
+	opened := map[string][]TimelineEntry{}
+	for _, entry := range timeline.Timeline() {
+		if entry.Call == "open" {
+			opened[entry.OutputID] = append(opened[entry.OutputID], entry)
+		}
+	}
+	for _, outputID := range outputs {
+		decks := opened[outputID]
+		if len(decks) != 2 || decks[0].Deck == decks[1].Deck {
+			t.Fatalf("%s did not cue on its other deck: %+v", outputID, decks)
+		}
+		entries := waitForCall(t, timeline, decks[0].Instance, "close")
+		if down := volumes(entries, decks[0].Instance); len(down) < 2 {
+			t.Fatalf("%s cut instead of crossfading: %v", outputID, down)
+		}
+	}
+}
+
+func TestTimelinePlaySyncStopsOutputsWhenAStartFails(t *testing.T) {
+	m, timeline := newTimelineManager(t, "a.mp4")
+	timeline.Durations["a.mp4"] = time.Hour
+	m.ConfigureOutputDevices([]OutputDevice{{ID: "display-1", Name: "Display 1", Type: "video"}})
+	err := m.PlaySync("wall", []SyncItem{
+		{OutputID: testOutput, Request: PlayRequest{AssetPath: "a.mp4"}},
+		// A timed item skips the prepare round, so it fails on play.
+		{OutputID: "display-1", Request: PlayRequest{AssetPath: "missing.mp4", StartAt: time.Now().Add(time.Hour)}},
+	})
+	if err == nil {
+		t.Fatal("sync group with a missing asset started")
+	}
+	for _, status := range m.Status() {
+		if status.Active {
+			t.Fatalf("output left cued after a failed sync start: %+v", status)
+		}
+	}
+}
+
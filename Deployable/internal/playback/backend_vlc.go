@@ -50,6 +50,11 @@ func (b *VLCBackend) Open(assetPath string, output OutputDevice) (BackendInstanc
 	}, nil
 }
 
+// OpenDeck needs nothing per deck: every Open creates its own player.
+func (b *VLCBackend) OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error) {
+	return b.Open(assetPath, output)
+}
+
 func (b *VLCBackend) init() error {
 	b.initOnce.Do(func() {
 		b.initErr = libvlc.Init("--no-video-title-show")
@@ -58,14 +63,32 @@ func (b *VLCBackend) init() error {
 }
 
 type VLCInstance struct {
-	player *libvlc.Player
-	media  *libvlc.Media
+	player   *libvlc.Player
+	media    *libvlc.Media
+	prepared bool
 }
 
 func (v *VLCInstance) Play() error {
+	if v.prepared {
+		v.prepared = false
+		return v.player.SetPause(false)
+	}
 	return v.player.Play()
 }
 
+// Prepare starts the media with the start-paused input option, leaving it
+// on its first frame.
+func (v *VLCInstance) Prepare() error {
+	if err := v.media.AddOption(":start-paused"); err != nil {
+		return err
+	}
+	if err := v.player.Play(); err != nil {
+		return err
+	}
+	v.prepared = true
+	return nil
+}
+
 func (v *VLCInstance) Stop() error {
 	return v.player.Stop()
 }
@@ -103,6 +126,19 @@ func (v *VLCInstance) SetLoop(loop bool) error {
 	return v.media.AddOption("input-repeat=0")
 }
 
+func (v *VLCInstance) Status() (MediaStatus, bool) {
+	position, err := v.player.MediaTime()
+	if err != nil {
+		return MediaStatus{}, false
+	}
+	length, _ := v.player.MediaLength()
+	return MediaStatus{
+		Playing:    v.player.IsPlaying(),
+		PositionMs: position,
+		DurationMs: length,
+	}, true
+}
+
 func (v *VLCInstance) Close() error {
 	if v.media != nil {
 		v.media.Release()
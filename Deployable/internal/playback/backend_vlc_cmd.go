@@ -5,26 +5,69 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"log"
 	"net"
 	"os/exec"
-	"runtime"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 )
 
+// Restart modes for a VLC process that exits or drops its RC connection.
+const (
+	// RestartResume restarts VLC and continues the last media from its
+	// estimated position.
+	RestartResume = "resume"
+	// RestartReload restarts VLC and plays the last media from the start.
+	RestartReload = "restart"
+	// RestartIdle restarts VLC without loading media.
+	RestartIdle = "idle"
+	// RestartOff leaves the output dark until the next play.
+	RestartOff = "off"
+)
+
+type RestartPolicy struct {
+	Mode string
+	// MaxRestarts limits restarts within Window; zero means unlimited.
+	MaxRestarts int
+	Window      time.Duration
+	Backoff     time.Duration
+}
+
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Mode:        RestartResume,
+		MaxRestarts: 5,
+		Window:      time.Minute,
+		Backoff:     time.Second,
+	}
+}
+
+// VLCCommandBackend drives VLC through its RC interface. Each output keeps
+// one long-lived VLC process; opening media swaps the playlist instead of
+// starting a new process, so the fullscreen window stays up across state
+// changes. The process is supervised and restarted according to Restart.
+// A crossfade starts a second process for the output's other deck.
 type VLCCommandBackend struct {
 	VLCPath string
 	Debug   bool
+	Restart RestartPolicy
+
+	mu      sync.Mutex
+	players map[string]*vlcPlayer
+	events  func(Event)
 }
 
 func NewVLCCommandBackend(path string) *VLCCommandBackend {
 	if strings.TrimSpace(path) == "" {
 		path = "vlc"
 	}
-	return &VLCCommandBackend{VLCPath: path}
+	return &VLCCommandBackend{
+		VLCPath: path,
+		Restart: DefaultRestartPolicy(),
+		players: make(map[string]*vlcPlayer),
+	}
 }
 
 func NewVLCCommandBackendWithDebug(path string, debug bool) *VLCCommandBackend {
@@ -33,13 +76,111 @@ func NewVLCCommandBackendWithDebug(path string, debug bool) *VLCCommandBackend {
 	return backend
 }
 
+func (b *VLCCommandBackend) SetEventHandler(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = fn
+}
+
 func (b *VLCCommandBackend) Open(assetPath string, output OutputDevice) (BackendInstance, error) {
+	return b.OpenDeck(assetPath, output, 0)
+}
+
+func (b *VLCCommandBackend) OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error) {
+	player := b.player(output, deck)
+	gen, err := player.claim()
+	if err != nil {
+		return nil, err
+	}
+	return &VLCCommandInstance{
+		player:    player,
+		gen:       gen,
+		assetPath: assetPath,
+	}, nil
+}
+
+// OutputStatus reports the VLC process behind an output, and the one
+// behind its second deck once a crossfade has started it.
+func (b *VLCCommandBackend) OutputStatus(outputID string) map[string]any {
+	b.mu.Lock()
+	player, ok := b.players[outputID]
+	second := b.players[deckKey(outputID, 1)]
+	b.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	status := player.status()
+	if second != nil {
+		status["deck_b"] = second.status()
+	}
+	return status
+}
+
+// PositionResolution is a second: RC reports and seeks whole seconds.
+func (b *VLCCommandBackend) PositionResolution() time.Duration {
+	return time.Second
+}
+
+// Close quits every VLC process started by the backend.
+func (b *VLCCommandBackend) Close() error {
+	b.mu.Lock()
+	players := b.players
+	b.players = make(map[string]*vlcPlayer)
+	b.mu.Unlock()
+	for _, player := range players {
+		player.close()
+	}
+	return nil
+}
+
+func (b *VLCCommandBackend) player(output OutputDevice, deck int) *vlcPlayer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.players == nil {
+		b.players = make(map[string]*vlcPlayer)
+	}
+	key := deckKey(output.ID, deck)
+	player, ok := b.players[key]
+	if !ok {
+		player = &vlcPlayer{backend: b, output: output, media: vlcMedia{volume: 1.0}}
+		b.players[key] = player
+	}
+	return player
+}
+
+func (b *VLCCommandBackend) restartPolicy() RestartPolicy {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	policy := b.Restart
+	if policy.Mode == "" {
+		policy.Mode = RestartResume
+	}
+	return policy
+}
+
+func (b *VLCCommandBackend) emit(event Event) {
+	event.Timestamp = time.Now().UTC()
+	if event.Error != "" {
+		log.Printf("vlc %s: %s: %s", event.OutputID, event.Type, event.Error)
+	} else {
+		log.Printf("vlc %s: %s", event.OutputID, event.Type)
+	}
+	b.mu.Lock()
+	fn := b.events
+	b.mu.Unlock()
+	if fn != nil {
+		fn(event)
+	}
+}
+
+// start launches an idle VLC for output and connects to its RC interface.
+func (b *VLCCommandBackend) start(output OutputDevice) (*vlcProcess, error) {
 	rcPort, err := pickPort()
 	if err != nil {
 		return nil, err
 	}
 	bind := fmt.Sprintf("127.0.0.1:%d", rcPort)
-	rcArgs := []string{
+	args := []string{
 		"--intf", "dummy",
 		"--extraintf", "rc",
 		"--rc-host", bind,
@@ -47,26 +188,22 @@ func (b *VLCCommandBackend) Open(assetPath string, output OutputDevice) (Backend
 		"--no-video-title-show",
 	}
 	if isCVLCPath(b.VLCPath) {
-		rcArgs = []string{
+		args = []string{
 			"-I", "rc",
 			"--rc-host", bind,
 			"--quiet",
 			"--no-video-title-show",
 		}
 	}
-	args := rcArgs
 	if output.Type == "video" {
 		args = append(args, "--fullscreen")
 		if idx, ok := parseDisplayIndex(output.ID); ok && !isCVLCPath(b.VLCPath) {
 			args = append(args, "--qt-fullscreen-screennumber="+strconv.Itoa(idx))
 		}
 	}
-	args = append(args, assetPath)
 
 	cmd := exec.Command(b.VLCPath, args...)
-	if runtime.GOOS == "windows" {
-		cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-	}
+	hideWindow(cmd)
 	var stderr bytes.Buffer
 	if b.Debug {
 		cmd.Stderr = &stderr
@@ -74,39 +211,577 @@ func (b *VLCCommandBackend) Open(assetPath string, output OutputDevice) (Backend
 	if err := cmd.Start(); err != nil {
 		return nil, err
 	}
-	_ = cmd.Process.Release()
-	conn, err := connectRC(rcPort, cmd)
+	proc := &vlcProcess{
+		cmd:          cmd,
+		exited:       make(chan struct{}),
+		disconnected: make(chan struct{}),
+	}
+	go func() {
+		proc.waitErr = cmd.Wait()
+		close(proc.exited)
+	}()
+	conn, err := connectRC(rcPort, proc.exited)
 	if err != nil {
+		proc.kill()
 		if b.Debug && stderr.Len() > 0 {
 			return nil, fmt.Errorf("vlc rc connect failed: %w; stderr: %s", err, strings.TrimSpace(stderr.String()))
 		}
-		_ = cmd.Process.Kill()
 		return nil, err
 	}
-	return &VLCCommandInstance{
-		cmd:      cmd,
-		conn:     conn,
-		writer:   bufio.NewWriter(conn),
-		volume:   1.0,
-		paused:   false,
-	}, nil
+	proc.conn = conn
+	proc.writer = bufio.NewWriter(conn)
+	go proc.drain()
+	return proc, nil
+}
+
+const (
+	statusPollInterval = time.Second
+	statusPollTimeout  = 3 * time.Second
+)
+
+type vlcProcess struct {
+	cmd          *exec.Cmd
+	conn         net.Conn
+	writer       *bufio.Writer
+	exited       chan struct{}
+	disconnected chan struct{}
+	waitErr      error
+
+	// Status polled over RC, guarded by mu. pending lists the queries
+	// whose answers have not arrived yet.
+	mu       sync.Mutex
+	pending  []string
+	polledAt time.Time
+	queried  time.Time
+	polled   bool
+	playing  bool
+	timeMs   int
+	lengthMs int
+}
+
+// drain reads RC output so VLC never blocks on a full socket, matches
+// numeric lines to pending status queries, and marks the process
+// disconnected when the connection ends.
+func (p *vlcProcess) drain() {
+	defer close(p.disconnected)
+	scanner := bufio.NewScanner(p.conn)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(scanner.Text(), "> "))
+		if n, err := strconv.Atoi(line); err == nil {
+			p.answer(n)
+		}
+	}
+}
+
+// beginPoll returns the status queries to send, or nil while an earlier
+// poll is still in flight.
+func (p *vlcProcess) beginPoll(now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) > 0 && now.Sub(p.queried) < statusPollTimeout {
+		return nil
+	}
+	p.pending = []string{"is_playing", "get_time", "get_length"}
+	p.queried = now
+	return append([]string(nil), p.pending...)
+}
+
+func (p *vlcProcess) answer(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) == 0 {
+		return
+	}
+	query := p.pending[0]
+	p.pending = p.pending[1:]
+	switch query {
+	case "is_playing":
+		p.playing = n == 1
+		if !p.playing {
+			// VLC does not answer time queries without an input.
+			p.pending = nil
+			p.polled, p.polledAt = true, time.Now()
+		}
+	case "get_time":
+		p.timeMs = n * 1000
+	case "get_length":
+		p.lengthMs = n * 1000
+		p.polled, p.polledAt = true, time.Now()
+	}
+}
+
+// resetStatus forgets polled values when the media changes.
+func (p *vlcProcess) resetStatus() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = nil
+	p.polled = false
+	p.playing = false
+	p.timeMs, p.lengthMs = 0, 0
+}
+
+// polledStatus extrapolates the last poll to now. ok is false until a
+// poll for the current media has completed.
+func (p *vlcProcess) polledStatus(now time.Time, paused bool) (MediaStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.polled {
+		return MediaStatus{}, false
+	}
+	status := MediaStatus{Playing: p.playing, PositionMs: p.timeMs, DurationMs: p.lengthMs}
+	if p.playing && !paused {
+		status.PositionMs += int(now.Sub(p.polledAt) / time.Millisecond)
+	}
+	if status.DurationMs > 0 && status.PositionMs > status.DurationMs {
+		status.PositionMs = status.DurationMs
+	}
+	return status, true
+}
+
+func (p *vlcProcess) write(commands ...string) error {
+	for _, command := range commands {
+		if _, err := p.writer.WriteString(command + "\n"); err != nil {
+			_ = p.conn.Close()
+			return err
+		}
+	}
+	if err := p.writer.Flush(); err != nil {
+		_ = p.conn.Close()
+		return err
+	}
+	return nil
+}
+
+func (p *vlcProcess) kill() {
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	select {
+	case <-p.exited:
+	default:
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+func (p *vlcProcess) exitReason() string {
+	select {
+	case <-p.exited:
+		if p.waitErr != nil {
+			return "vlc exited: " + p.waitErr.Error()
+		}
+		return "vlc exited"
+	default:
+		return "vlc rc connection lost"
+	}
+}
+
+// vlcMedia is the last requested playback on an output, kept so a
+// restarted VLC can pick up where the old one stopped.
+type vlcMedia struct {
+	asset      string
+	loop       bool
+	volume     float64
+	playing    bool
+	paused     bool
+	positionMs int
+	durationMs int
+	at         time.Time
+}
+
+func (m vlcMedia) position(now time.Time) int {
+	if m.playing && !m.paused {
+		return m.positionMs + int(now.Sub(m.at)/time.Millisecond)
+	}
+	return m.positionMs
+}
+
+// vlcPlayer is the supervised VLC process bound to an output. Only the
+// instance holding the current generation may control it, so closing a
+// replaced instance never stops the media that replaced it.
+type vlcPlayer struct {
+	backend *VLCCommandBackend
+	output  OutputDevice
+
+	mu         sync.Mutex
+	proc       *vlcProcess
+	current    uint64
+	media      vlcMedia
+	closed     bool
+	restarting bool
+	starting   chan struct{}
+	restarts   int
+	recent     []time.Time
+	lastExit   string
+	lastExitAt time.Time
+}
+
+// claim makes a new generation current, starting VLC if it is not running.
+func (p *vlcPlayer) claim() (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.ensureLocked(); err != nil {
+		return 0, err
+	}
+	p.current++
+	return p.current, nil
+}
+
+// ensureLocked starts VLC unless it is running, waiting for a start that
+// is already under way.
+func (p *vlcPlayer) ensureLocked() error {
+	for p.starting != nil {
+		starting := p.starting
+		p.mu.Unlock()
+		<-starting
+		p.mu.Lock()
+	}
+	if p.closed {
+		return errors.New("vlc backend closed")
+	}
+	if p.proc != nil {
+		return nil
+	}
+	if p.restarting {
+		return errors.New("vlc is restarting")
+	}
+	return p.startLocked()
+}
+
+// startLocked releases p.mu while VLC starts and its RC port is
+// connected, which can take seconds, so status and polling are not held
+// up meanwhile.
+func (p *vlcPlayer) startLocked() error {
+	starting := make(chan struct{})
+	p.starting = starting
+	p.mu.Unlock()
+	proc, err := p.backend.start(p.output)
+	p.mu.Lock()
+	p.starting = nil
+	close(starting)
+	if err != nil {
+		return err
+	}
+	if p.closed {
+		proc.kill()
+		return errors.New("vlc backend closed")
+	}
+	p.proc = proc
+	go p.supervise(proc)
+	go p.poll(proc)
+	return nil
+}
+
+// poll asks VLC for its playback state while media is loaded.
+func (p *vlcPlayer) poll(proc *vlcProcess) {
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-proc.exited:
+			return
+		case <-proc.disconnected:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			if p.proc != proc {
+				p.mu.Unlock()
+				return
+			}
+			if p.media.playing {
+				if queries := proc.beginPoll(now); queries != nil {
+					_ = proc.write(queries...)
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// mediaStatus reports on the media of generation gen.
+func (p *vlcPlayer) mediaStatus(gen uint64) (MediaStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if gen != p.current || p.proc == nil || !p.media.playing {
+		return MediaStatus{}, false
+	}
+	return p.proc.polledStatus(time.Now(), p.media.paused)
+}
+
+// send records the change in the media state and writes commands for
+// generation gen. Both are dropped once a newer instance has claimed the
+// player.
+func (p *vlcPlayer) send(gen uint64, update func(*vlcMedia), commands ...string) error {
+	return p.sendMedia(gen, false, update, commands...)
+}
+
+// load is send for commands that replace the media, so status polled for
+// the previous media is discarded.
+func (p *vlcPlayer) load(gen uint64, update func(*vlcMedia), commands ...string) error {
+	return p.sendMedia(gen, true, update, commands...)
+}
+
+func (p *vlcPlayer) sendMedia(gen uint64, replace bool, update func(*vlcMedia), commands ...string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if gen != p.current {
+		return nil
+	}
+	if update != nil {
+		update(&p.media)
+	}
+	if err := p.ensureLocked(); err != nil {
+		return err
+	}
+	if gen != p.current {
+		// Claimed by a newer instance while VLC was starting.
+		return nil
+	}
+	if replace {
+		p.proc.resetStatus()
+	}
+	return p.proc.write(commands...)
+}
+
+func (p *vlcPlayer) supervise(proc *vlcProcess) {
+	select {
+	case <-proc.exited:
+	case <-proc.disconnected:
+		// A dying process usually drops the socket first; give it a
+		// moment so the reason names the exit.
+		select {
+		case <-proc.exited:
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	reason := proc.exitReason()
+	p.mu.Lock()
+	if p.closed || p.proc != proc {
+		p.mu.Unlock()
+		return
+	}
+	p.proc = nil
+	proc.kill()
+	now := time.Now()
+	if polled, ok := proc.polledStatus(now, p.media.paused); ok && polled.Playing {
+		p.media.positionMs, p.media.at = polled.PositionMs, now
+		p.media.durationMs = polled.DurationMs
+	}
+	p.lastExit, p.lastExitAt = reason, now
+	p.mu.Unlock()
+	p.backend.emit(Event{OutputID: p.output.ID, Type: EventPlayerExited, Error: reason})
+	p.restart()
+}
+
+func (p *vlcPlayer) restart() {
+	policy := p.backend.restartPolicy()
+	if policy.Mode == RestartOff {
+		return
+	}
+	for {
+		p.mu.Lock()
+		if p.closed || p.proc != nil || p.starting != nil {
+			p.mu.Unlock()
+			return
+		}
+		if !p.allowRestartLocked(policy, time.Now()) {
+			p.mu.Unlock()
+			p.backend.emit(Event{
+				OutputID: p.output.ID,
+				Type:     EventPlayerFailed,
+				Error:    fmt.Sprintf("gave up after %d restarts within %s", policy.MaxRestarts, policy.Window),
+			})
+			return
+		}
+		p.restarting = true
+		p.mu.Unlock()
+
+		time.Sleep(policy.Backoff)
+
+		p.mu.Lock()
+		p.restarting = false
+		if p.closed || p.proc != nil || p.starting != nil {
+			p.mu.Unlock()
+			return
+		}
+		if err := p.startLocked(); err != nil {
+			p.lastExit, p.lastExitAt = "restart failed: "+err.Error(), time.Now()
+			p.mu.Unlock()
+			log.Printf("vlc %s: restart failed: %v", p.output.ID, err)
+			continue
+		}
+		p.restarts++
+		restarts := p.restarts
+		// A failed restore closes the connection, which the new
+		// process's supervisor handles.
+		_ = p.proc.write(p.restoreLocked(policy.Mode)...)
+		p.mu.Unlock()
+		p.backend.emit(Event{OutputID: p.output.ID, Type: EventPlayerRestarted, Restarts: restarts})
+		return
+	}
+}
+
+func (p *vlcPlayer) allowRestartLocked(policy RestartPolicy, now time.Time) bool {
+	if policy.MaxRestarts <= 0 {
+		return true
+	}
+	recent := p.recent[:0]
+	for _, at := range p.recent {
+		if policy.Window <= 0 || now.Sub(at) < policy.Window {
+			recent = append(recent, at)
+		}
+	}
+	p.recent = recent
+	if len(p.recent) >= policy.MaxRestarts {
+		return false
+	}
+	p.recent = append(p.recent, now)
+	return true
+}
+
+// restoreLocked returns the commands that bring a fresh VLC back to the
+// last requested media and updates the media state to match.
+func (p *vlcPlayer) restoreLocked(mode string) []string {
+	now := time.Now()
+	commands := []string{fmt.Sprintf("volume %d", int(clampVolume(p.media.volume)*256))}
+	if p.media.loop {
+		commands = append(commands, "loop on")
+	} else {
+		commands = append(commands, "loop off")
+	}
+	if !p.media.playing || p.media.asset == "" {
+		return commands
+	}
+	if mode == RestartIdle {
+		p.media.playing = false
+		p.media.paused = false
+		return commands
+	}
+	position := 0
+	if mode == RestartResume {
+		position = p.media.position(now)
+		if p.media.loop && p.media.durationMs > 0 {
+			position %= p.media.durationMs
+		}
+	}
+	commands = append(commands, "add "+p.media.asset)
+	if position >= 1000 {
+		commands = append(commands, fmt.Sprintf("seek %d", position/1000))
+	}
+	if p.media.paused {
+		commands = append(commands, "pause")
+	}
+	p.media.positionMs, p.media.at = position, now
+	return commands
+}
+
+func (p *vlcPlayer) status() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := map[string]any{
+		"running":    p.proc != nil,
+		"restarting": p.restarting,
+		"restarts":   p.restarts,
+	}
+	if p.proc != nil && p.proc.cmd.Process != nil {
+		status["pid"] = p.proc.cmd.Process.Pid
+	}
+	if p.lastExit != "" {
+		status["last_exit"] = p.lastExit
+		status["last_exit_at"] = p.lastExitAt.UTC()
+	}
+	return status
+}
+
+func (p *vlcPlayer) close() {
+	p.mu.Lock()
+	p.closed = true
+	proc := p.proc
+	p.proc = nil
+	p.mu.Unlock()
+	if proc == nil {
+		return
+	}
+	_ = proc.write("quit")
+	select {
+	case <-proc.exited:
+	case <-time.After(2 * time.Second):
+	}
+	proc.kill()
 }
 
 type VLCCommandInstance struct {
-	mu     sync.Mutex
-	cmd    *exec.Cmd
-	conn   net.Conn
-	writer *bufio.Writer
-	volume float64
-	paused bool
+	player    *vlcPlayer
+	gen       uint64
+	assetPath string
+
+	mu      sync.Mutex
+	paused  bool
+	started bool
+	startMs int
 }
 
+// Play swaps the player's playlist to this instance's media. clear and
+// add are sent together so VLC moves straight to the new item.
 func (v *VLCCommandInstance) Play() error {
-	return v.send("play")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	if v.started {
+		err := v.player.send(v.gen, func(m *vlcMedia) {
+			if m.paused {
+				m.paused, m.at = false, now
+			}
+		}, "play")
+		if err == nil {
+			v.paused = false
+		}
+		return err
+	}
+	commands := []string{"clear", "add " + v.assetPath}
+	if v.startMs > 0 {
+		commands = append(commands, fmt.Sprintf("seek %d", v.startMs/1000))
+	}
+	err := v.player.load(v.gen, func(m *vlcMedia) {
+		m.asset, m.playing, m.paused = v.assetPath, true, false
+		m.positionMs, m.durationMs, m.at = v.startMs, 0, now
+	}, commands...)
+	if err != nil {
+		return err
+	}
+	v.started = true
+	v.paused = false
+	return nil
+}
+
+// Prepare adds the media paused on its first frame using VLC's per-item
+// start-paused option; Play then only has to unpause it.
+func (v *VLCCommandInstance) Prepare() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	item := "add " + v.assetPath + " :start-paused"
+	if v.startMs > 0 {
+		item += " :start-time=" + formatSeconds(v.startMs)
+	}
+	now := time.Now()
+	err := v.player.load(v.gen, func(m *vlcMedia) {
+		m.asset, m.playing, m.paused = v.assetPath, true, true
+		m.positionMs, m.durationMs, m.at = v.startMs, 0, now
+	}, "clear", item)
+	if err != nil {
+		return err
+	}
+	v.started = true
+	v.paused = true
+	return nil
 }
 
 func (v *VLCCommandInstance) Stop() error {
-	return v.send("stop")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.started = false
+	return v.player.load(v.gen, func(m *vlcMedia) {
+		m.asset, m.playing, m.paused, m.positionMs = "", false, false, 0
+	}, "stop", "clear")
 }
 
 func (v *VLCCommandInstance) Pause() error {
@@ -115,7 +790,11 @@ func (v *VLCCommandInstance) Pause() error {
 	if v.paused {
 		return nil
 	}
-	if err := v.sendLocked("pause"); err != nil {
+	now := time.Now()
+	err := v.player.send(v.gen, func(m *vlcMedia) {
+		m.positionMs, m.paused = m.position(now), true
+	}, "pause")
+	if err != nil {
 		return err
 	}
 	v.paused = true
@@ -128,59 +807,57 @@ func (v *VLCCommandInstance) Resume() error {
 	if !v.paused {
 		return nil
 	}
-	if err := v.sendLocked("pause"); err != nil {
+	now := time.Now()
+	err := v.player.send(v.gen, func(m *vlcMedia) {
+		m.paused, m.at = false, now
+	}, "pause")
+	if err != nil {
 		return err
 	}
 	v.paused = false
 	return nil
 }
 
+// Seek before Play is remembered and applied once the media is added.
 func (v *VLCCommandInstance) Seek(ms int) error {
-	seconds := ms / 1000
-	return v.send(fmt.Sprintf("seek %d", seconds))
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.started {
+		v.startMs = ms
+		return nil
+	}
+	now := time.Now()
+	return v.player.send(v.gen, func(m *vlcMedia) {
+		m.positionMs, m.at = ms, now
+	}, fmt.Sprintf("seek %d", ms/1000))
 }
 
 func (v *VLCCommandInstance) SetVolume(vol float64) error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	v.volume = vol
 	value := int(clampVolume(vol) * 256)
-	return v.sendLocked(fmt.Sprintf("volume %d", value))
+	return v.player.send(v.gen, func(m *vlcMedia) {
+		m.volume = vol
+	}, fmt.Sprintf("volume %d", value))
 }
 
 func (v *VLCCommandInstance) SetLoop(loop bool) error {
+	command := "loop off"
 	if loop {
-		return v.send("loop on")
+		command = "loop on"
 	}
-	return v.send("loop off")
+	return v.player.send(v.gen, func(m *vlcMedia) {
+		m.loop = loop
+	}, command)
 }
 
-func (v *VLCCommandInstance) Close() error {
-	_ = v.send("stop")
-	_ = v.send("quit")
-	if v.conn != nil {
-		_ = v.conn.Close()
-	}
-	if v.cmd != nil && v.cmd.Process != nil {
-		_ = v.cmd.Process.Kill()
-	}
-	return nil
+// Status reports the state VLC last answered over RC, extrapolated to
+// now. RC reports whole seconds, so positions are only that precise.
+func (v *VLCCommandInstance) Status() (MediaStatus, bool) {
+	return v.player.mediaStatus(v.gen)
 }
 
-func (v *VLCCommandInstance) send(command string) error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-	return v.sendLocked(command)
-}
-
-func (v *VLCCommandInstance) sendLocked(command string) error {
-	if v.writer == nil {
-		return errors.New("vlc rc not connected")
-	}
-	if _, err := v.writer.WriteString(command + "\n"); err != nil {
-		return err
-	}
-	return v.writer.Flush()
+// Close leaves the VLC process running for the next media.
+func (v *VLCCommandInstance) Close() error {
+	return nil
 }
 
 func pickPort() (int, error) {
@@ -192,22 +869,20 @@ func pickPort() (int, error) {
 	return listener.Addr().(*net.TCPAddr).Port, nil
 }
 
-func connectRC(port int, cmd *exec.Cmd) (net.Conn, error) {
+func connectRC(port int, exited <-chan struct{}) (net.Conn, error) {
 	address := fmt.Sprintf("127.0.0.1:%d", port)
 	var lastErr error
 	for i := 0; i < 50; i++ {
 		conn, err := net.DialTimeout("tcp", address, 200*time.Millisecond)
 		if err == nil {
-			_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
-			_, _ = conn.Write([]byte("status\n"))
-			_ = conn.SetDeadline(time.Time{})
 			return conn, nil
 		}
 		lastErr = err
-		if cmd != nil && cmd.ProcessState != nil && cmd.ProcessState.Exited() {
+		select {
+		case <-exited:
 			return nil, errors.New("vlc exited before rc became available")
+		case <-time.After(100 * time.Millisecond):
 		}
-		time.Sleep(100 * time.Millisecond)
 	}
 	if lastErr == nil {
 		lastErr = errors.New("failed to connect to vlc rc")
@@ -0,0 +1,102 @@
+package playback
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// FadeCurve shapes how volume moves over a fade.
+type FadeCurve string
+
+const (
+	FadeLinear      FadeCurve = "linear"
+	FadeEqualPower  FadeCurve = "equal_power"
+	FadeLogarithmic FadeCurve = "logarithmic"
+	FadeSCurve      FadeCurve = "s_curve"
+)
+
+// fadeStepMs is how often a running fade updates the volume.
+const fadeStepMs = 50
+
+// fadeFloor is the level logarithmic fades treat as silence, -60 dB.
+const fadeFloor = 0.001
+
+// ParseFadeCurve accepts curve names with dashes or underscores; empty
+// means linear.
+func ParseFadeCurve(name string) (FadeCurve, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+	switch normalized {
+	case "", "linear":
+		return FadeLinear, nil
+	case "equal_power":
+		return FadeEqualPower, nil
+	case "logarithmic", "log":
+		return FadeLogarithmic, nil
+	case "s_curve", "scurve":
+		return FadeSCurve, nil
+	}
+	return "", fmt.Errorf("unknown fade curve: %s", name)
+}
+
+// level returns the volume at progress (0 to 1) of a fade from from to to.
+// Curves are defined for rising fades; falling fades use the mirror image,
+// so an equal-power fade-out follows a cosine and a fade-in a sine.
+func (c FadeCurve) level(from, to, progress float64) float64 {
+	if progress <= 0 {
+		return from
+	}
+	if progress >= 1 {
+		return to
+	}
+	if to >= from {
+		return from + (to-from)*c.rise(progress)
+	}
+	return from + (to-from)*(1-c.rise(1-progress))
+}
+
+func (c FadeCurve) rise(p float64) float64 {
+	switch c {
+	case FadeEqualPower:
+		return math.Sin(p * math.Pi / 2)
+	case FadeLogarithmic:
+		// Linear in decibels from fadeFloor up to full level.
+		return (math.Pow(fadeFloor, 1-p) - fadeFloor) / (1 - fadeFloor)
+	case FadeSCurve:
+		return p * p * (3 - 2*p)
+	default:
+		return p
+	}
+}
+
+// fadeState is the fade a channel is running, kept for status reporting
+// and so a new fade can start from where this one got to.
+type fadeState struct {
+	from      float64
+	to        float64
+	curve     FadeCurve
+	start     time.Time
+	duration  time.Duration
+	stopAfter bool
+}
+
+func (f *fadeState) progress(now time.Time) float64 {
+	if f.duration <= 0 {
+		return 1
+	}
+	return float64(now.Sub(f.start)) / float64(f.duration)
+}
+
+func (f *fadeState) level(now time.Time) float64 {
+	return clampVolume(f.curve.level(f.from, f.to, f.progress(now)))
+}
+
+func (f *fadeState) remaining(now time.Time) time.Duration {
+	remaining := f.duration - now.Sub(f.start)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package playback
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+func mpvIPCPath(outputID string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("deployable-mpv-%d-%s.sock", os.Getpid(), outputID))
+}
+
+func dialMPV(path string) (net.Conn, error) {
+	return net.Dial("unix", path)
+}
+
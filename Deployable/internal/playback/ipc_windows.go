@@ -0,0 +1,21 @@
+//go:build windows
+
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+func mpvIPCPath(outputID string) string {
+	return fmt.Sprintf(`\\.\pipe\deployable-mpv-%d-%s`, os.Getpid(), outputID)
+}
+
+// mpv serves IPC over a named pipe on Windows, which needs overlapped I/O
+// the standard library does not provide for pipes.
+func dialMPV(path string) (net.Conn, error) {
+	return nil, errors.New("the mpv backend is not supported on Windows; use vlc")
+}
+
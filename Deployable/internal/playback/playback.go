@@ -6,10 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"deployable/internal/types"
 )
 
 type PlaybackService interface {
@@ -19,8 +22,11 @@ type PlaybackService interface {
 	Pause(outputID string) error
 	Resume(outputID string) error
 	SetVolume(outputID string, volume float64) error
-	FadeVolume(outputID string, target float64, durationMs int) error
+	FadeVolume(outputID string, target float64, durationMs int, curve FadeCurve) error
+	FadeOut(outputID string, durationMs int, curve FadeCurve) error
 	Seek(outputID string, positionMs int) error
+	Prepare(outputID string, req PlayRequest) error
+	PlaySync(group string, items []SyncItem) error
 	Snapshot() map[string]any
 }
 
@@ -36,6 +42,15 @@ type PlayRequest struct {
 	StartMs   int
 	Volume    *float64
 	FadeInMs  int
+	// CrossfadeMs blends from the output's current media instead of
+	// cutting to it.
+	CrossfadeMs int
+	// FadeCurve shapes the fade-in and crossfade.
+	FadeCurve FadeCurve
+	// StartAt, when set, is the local time the media should start. The
+	// media is cued until then; past it, playback starts as far into the
+	// media as it would be by now.
+	StartAt time.Time
 }
 
 type MediaBackend interface {
@@ -53,22 +68,113 @@ type BackendInstance interface {
 	Close() error
 }
 
+// DeckOpener is implemented by backends that can run two media at once on
+// one output, which crossfades need. deck is 0 or 1; Open uses deck 0.
+type DeckOpener interface {
+	OpenDeck(assetPath string, output OutputDevice, deck int) (BackendInstance, error)
+}
+
+// deckKey names the player of an output's deck, for backends that keep
+// one player per deck.
+func deckKey(outputID string, deck int) string {
+	if deck == 0 {
+		return outputID
+	}
+	return outputID + "-deck" + strconv.Itoa(deck)
+}
+
+// OpacitySetter is implemented by backend instances that can blend their
+// video over the other deck.
+type OpacitySetter interface {
+	SetOpacity(opacity float64) error
+}
+
+// Raiser is implemented by backend instances that cannot blend video but
+// can bring their window in front of the other deck.
+type Raiser interface {
+	Raise() error
+}
+
+// MediaStatus is what a backend instance reports about its media.
+type MediaStatus struct {
+	Playing    bool
+	PositionMs int
+	DurationMs int
+}
+
+// Preparer is implemented by backend instances that can load media paused
+// on its first frame, so that Play starts it without opening delay.
+type Preparer interface {
+	Prepare() error
+}
+
+// StatusReporter is implemented by backend instances that can report
+// playback position and state. ok is false while nothing is known yet.
+type StatusReporter interface {
+	Status() (status MediaStatus, ok bool)
+}
+
+// Playback events reported by backends.
+const (
+	EventPlayerExited    = "player_exited"
+	EventPlayerRestarted = "player_restarted"
+	EventPlayerFailed    = "player_failed"
+	EventMediaEnded      = "media_ended"
+	EventMediaError      = "media_error"
+)
+
+type Event struct {
+	OutputID  string    `json:"output_id"`
+	Type      string    `json:"type"`
+	Asset     string    `json:"asset,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Restarts  int       `json:"restarts,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventSource is implemented by backends that report events on their own,
+// such as a player process exiting.
+type EventSource interface {
+	SetEventHandler(fn func(Event))
+}
+
+// OutputStatusReporter is implemented by backends that run a player per
+// output and can report on it.
+type OutputStatusReporter interface {
+	OutputStatus(outputID string) map[string]any
+}
+
+// PositionResolver is implemented by backends that report and seek
+// positions more coarsely than to the millisecond.
+type PositionResolver interface {
+	PositionResolution() time.Duration
+}
+
 type Manager struct {
+	// SyncInterval is how often sync groups are checked for drift; zero
+	// disables drift correction. SyncTolerance is the drift allowed.
+	SyncInterval  time.Duration
+	SyncTolerance time.Duration
+
 	mu        sync.Mutex
 	assetsDir string
 	backend   MediaBackend
 	outputs   map[string]OutputDevice
 	channels  map[string]*channel
 	aliases   map[string]string
+	groups    map[string]*syncGroup
 }
 
 func NewManager(assetsDir string, backend MediaBackend) *Manager {
 	return &Manager{
-		assetsDir: assetsDir,
-		backend:   backend,
-		outputs:   make(map[string]OutputDevice),
-		channels:  make(map[string]*channel),
-		aliases:   make(map[string]string),
+		SyncInterval:  DefaultSyncInterval,
+		SyncTolerance: DefaultSyncTolerance,
+		assetsDir:     assetsDir,
+		backend:       backend,
+		outputs:       make(map[string]OutputDevice),
+		channels:      make(map[string]*channel),
+		aliases:       make(map[string]string),
+		groups:        make(map[string]*syncGroup),
 	}
 }
 
@@ -137,24 +243,131 @@ func (m *Manager) SetVolume(outputID string, volume float64) error {
 	return m.dispatch(outputID, &setVolumeCmd{volume: volume})
 }
 
-func (m *Manager) FadeVolume(outputID string, target float64, durationMs int) error {
-	return m.dispatch(outputID, &fadeVolumeCmd{target: target, durationMs: durationMs})
+func (m *Manager) FadeVolume(outputID string, target float64, durationMs int, curve FadeCurve) error {
+	return m.dispatch(outputID, &fadeVolumeCmd{target: target, durationMs: durationMs, curve: curve})
+}
+
+// FadeOut fades an output to silence and then stops it. It returns once
+// the fade has started.
+func (m *Manager) FadeOut(outputID string, durationMs int, curve FadeCurve) error {
+	return m.dispatch(outputID, &fadeOutCmd{durationMs: durationMs, curve: curve})
 }
 
 func (m *Manager) Seek(outputID string, positionMs int) error {
 	return m.dispatch(outputID, &seekCmd{positionMs: positionMs})
 }
 
+// Prepare cues media on an output: opened, paused on its first frame and
+// ready for a Play of the same asset. Anything playing on the output is
+// replaced.
+func (m *Manager) Prepare(outputID string, req PlayRequest) error {
+	return m.dispatch(outputID, &prepareCmd{req: req})
+}
+
+// SetEventHandler forwards backend events to fn. Event errors are also
+// kept as the output's last error.
+func (m *Manager) SetEventHandler(fn func(Event)) {
+	source, ok := m.backend.(EventSource)
+	if !ok {
+		return
+	}
+	source.SetEventHandler(func(event Event) {
+		if event.Error != "" {
+			m.mu.Lock()
+			ch := m.channels[event.OutputID]
+			m.mu.Unlock()
+			if ch != nil {
+				ch.recordError(event.Error)
+			}
+		}
+		if fn != nil {
+			fn(event)
+		}
+	})
+}
+
+// Status lists the playback status of every output, ordered by output ID.
+func (m *Manager) Status() []types.PlaybackStatus {
+	m.mu.Lock()
+	channels := make([]*channel, 0, len(m.channels))
+	for _, ch := range m.channels {
+		channels = append(channels, ch)
+	}
+	membership := map[string]string{}
+	for name, group := range m.groups {
+		for _, outputID := range group.outputs {
+			membership[outputID] = name
+		}
+	}
+	m.mu.Unlock()
+	out := make([]types.PlaybackStatus, 0, len(channels))
+	for _, ch := range channels {
+		status := ch.status()
+		status.SyncGroup = membership[status.OutputID]
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OutputID < out[j].OutputID })
+	return out
+}
+
+// Snapshot copies what it needs under m.mu and queries channels, groups
+// and the backend after releasing it, since a backend may be slow to
+// answer while a player is starting.
 func (m *Manager) Snapshot() map[string]any {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	channels := map[string]any{}
+	reporter, _ := m.backend.(OutputStatusReporter)
+	outputs := make(map[string]OutputDevice, len(m.outputs))
+	for id, output := range m.outputs {
+		outputs[id] = output
+	}
+	chans := make(map[string]*channel, len(m.channels))
 	for id, ch := range m.channels {
-		channels[id] = ch.snapshot()
+		chans[id] = ch
+	}
+	syncGroups := make(map[string]*syncGroup, len(m.groups))
+	for name, group := range m.groups {
+		syncGroups[name] = group
+	}
+	m.mu.Unlock()
+	channels := map[string]any{}
+	for id, ch := range chans {
+		snapshot := ch.snapshot()
+		if reporter != nil {
+			if status := reporter.OutputStatus(id); status != nil {
+				snapshot["player"] = status
+			}
+		}
+		channels[id] = snapshot
+	}
+	groups := map[string]any{}
+	for name, group := range syncGroups {
+		groups[name] = group.snapshot()
 	}
 	return map[string]any{
-		"outputs":  m.outputs,
-		"channels": channels,
+		"outputs":     outputs,
+		"channels":    channels,
+		"sync_groups": groups,
+	}
+}
+
+// Close stops every channel and releases backends that keep players
+// running between media, such as the VLC command backend.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	for name, group := range m.groups {
+		close(group.stop)
+		delete(m.groups, name)
+	}
+	ids := make([]string, 0, len(m.channels))
+	for id := range m.channels {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		_ = m.Stop(id)
+	}
+	if closer, ok := m.backend.(interface{ Close() error }); ok {
+		_ = closer.Close()
 	}
 }
 
@@ -244,6 +457,13 @@ type playCmd struct {
 
 func (c *playCmd) setResponse(resp chan<- error) { c.resp = resp }
 
+type prepareCmd struct {
+	req  PlayRequest
+	resp chan<- error
+}
+
+func (c *prepareCmd) setResponse(resp chan<- error) { c.resp = resp }
+
 type stopCmd struct{ resp chan<- error }
 func (c *stopCmd) setResponse(resp chan<- error) { c.resp = resp }
 
@@ -262,16 +482,42 @@ func (c *setVolumeCmd) setResponse(resp chan<- error) { c.resp = resp }
 type fadeVolumeCmd struct {
 	target     float64
 	durationMs int
+	curve      FadeCurve
 	resp       chan<- error
 }
 func (c *fadeVolumeCmd) setResponse(resp chan<- error) { c.resp = resp }
 
+type fadeOutCmd struct {
+	durationMs int
+	curve      FadeCurve
+	resp       chan<- error
+}
+
+func (c *fadeOutCmd) setResponse(resp chan<- error) { c.resp = resp }
+
+// fadeStopCmd is sent by a finished fade-out to stop the media it faded,
+// unless that media has been replaced since.
+type fadeStopCmd struct {
+	instance BackendInstance
+	resp     chan<- error
+}
+
+func (c *fadeStopCmd) setResponse(resp chan<- error) { c.resp = resp }
+
 type seekCmd struct {
 	positionMs int
 	resp       chan<- error
 }
 func (c *seekCmd) setResponse(resp chan<- error) { c.resp = resp }
 
+// startCmd is sent by the timer of a scheduled start.
+type startCmd struct {
+	sched *scheduledStart
+	resp  chan<- error
+}
+
+func (c *startCmd) setResponse(resp chan<- error) { c.resp = resp }
+
 type channel struct {
 	output    OutputDevice
 	backend   MediaBackend
@@ -280,7 +526,62 @@ type channel struct {
 	instance  BackendInstance
 	volume    float64
 	fadeCancel chan struct{}
+	cued      *deckCue
 	mu        sync.Mutex
+
+	// Guarded by mu: what was last requested, for status reporting.
+	asset       string
+	loop        bool
+	paused      bool
+	positionMs  int
+	positionAt  time.Time
+	lastError   string
+	lastErrorAt time.Time
+	prepared    *preparedMedia
+	deck        int
+	xfade       *crossfade
+	fade        *fadeState
+	scheduled   *scheduledStart
+}
+
+// crossfade is the outgoing deck of a running crossfade.
+type crossfade struct {
+	outgoing BackendInstance
+	incoming BackendInstance
+	asset    string
+	target   float64
+}
+
+// deckCue is media cued silent on the idle deck by a prepare with
+// crossfade_ms. A play of the same asset crossfades to it; anything else
+// that changes the output's media releases it.
+type deckCue struct {
+	path     string
+	instance BackendInstance
+	deck     int
+}
+
+func (d *deckCue) release() {
+	_ = d.instance.Stop()
+	_ = d.instance.Close()
+}
+
+// scheduledStart is media cued to start at a set time. It waits on the
+// idle deck when the backend has one and the output is busy, otherwise
+// it is prepared in place of the output's media.
+type scheduledStart struct {
+	path  string
+	req   PlayRequest
+	at    time.Time
+	timer *time.Timer
+	cue   BackendInstance
+	deck  int
+}
+
+type preparedMedia struct {
+	path    string
+	loop    bool
+	startMs int
 }
 
 func newChannel(backend MediaBackend, assetsDir string, output OutputDevice) *channel {
@@ -297,24 +598,46 @@ func newChannel(backend MediaBackend, assetsDir string, output OutputDevice) *ch
 
 func (c *channel) run() {
 	for cmd := range c.commands {
+		switch cmd.(type) {
+		case *playCmd:
+			c.cancelScheduled()
+		case *prepareCmd, *stopCmd, *fadeOutCmd, *pauseCmd, *resumeCmd:
+			c.cancelScheduled()
+			c.releaseCue()
+		}
+		var resp chan<- error
+		var err error
 		switch req := cmd.(type) {
 		case *playCmd:
-			req.resp <- c.handlePlay(req.req)
+			resp, err = req.resp, c.handlePlay(req.req)
+		case *prepareCmd:
+			resp, err = req.resp, c.handlePrepare(req.req)
 		case *stopCmd:
-			req.resp <- c.handleStop()
+			resp, err = req.resp, c.handleStop()
 		case *pauseCmd:
-			req.resp <- c.handlePause()
+			resp, err = req.resp, c.handlePause()
 		case *resumeCmd:
-			req.resp <- c.handleResume()
+			resp, err = req.resp, c.handleResume()
 		case *setVolumeCmd:
-			req.resp <- c.handleSetVolume(req.volume)
+			resp, err = req.resp, c.handleSetVolume(req.volume)
 		case *fadeVolumeCmd:
-			req.resp <- c.handleFade(req.target, req.durationMs)
+			resp, err = req.resp, c.handleFade(req.target, req.durationMs, req.curve)
+		case *fadeOutCmd:
+			resp, err = req.resp, c.handleFadeOut(req.durationMs, req.curve)
+		case *fadeStopCmd:
+			resp, err = req.resp, c.handleFadeStop(req.instance)
 		case *seekCmd:
-			req.resp <- c.handleSeek(req.positionMs)
+			resp, err = req.resp, c.handleSeek(req.positionMs)
+		case *startCmd:
+			resp, err = req.resp, c.handleStart(req.sched)
 		default:
 			cmd.setResponse(make(chan error, 1))
+			continue
+		}
+		if err != nil {
+			c.recordError(err.Error())
 		}
+		resp <- err
 	}
 }
 
@@ -326,31 +649,308 @@ func (c *channel) handlePlay(req PlayRequest) error {
 	if err != nil {
 		return err
 	}
-	if err := c.stopInstance(); err != nil {
+	cue := c.cued
+	c.cued = nil
+	if cue != nil && (cue.path != fullPath || req.StartAt.After(time.Now())) {
+		cue.release()
+		cue = nil
+	}
+	if !req.StartAt.IsZero() {
+		wait := time.Until(req.StartAt)
+		if wait > 0 {
+			return c.schedule(fullPath, req, wait)
+		}
+		req.StartMs -= int(wait / time.Millisecond)
+		req.StartAt = time.Time{}
+	}
+	if cue != nil {
+		return c.switchDeck(cue.instance, cue.deck, req, c.level())
+	}
+	if c.prepared != nil && c.prepared.path == fullPath && c.instance != nil {
+		return c.startPrepared(req)
+	}
+	if req.CrossfadeMs > 0 && c.instance != nil {
+		if opener, ok := c.backend.(DeckOpener); ok {
+			return c.crossfadeTo(fullPath, req, opener)
+		}
+		// Without a second deck, cut and fade the new media in.
+		if req.FadeInMs <= 0 {
+			req.FadeInMs = req.CrossfadeMs
+		}
+	}
+	instance, err := c.open(fullPath, req)
+	if err != nil {
+		return err
+	}
+	if req.FadeInMs > 0 {
+		target := c.volume
+		if req.Volume == nil {
+			target = 1.0
+			c.volume = target
+		}
+		_ = instance.SetVolume(0)
+		c.startFade(0, target, req.FadeInMs, req.FadeCurve, false)
+	}
+	if err := instance.Play(); err != nil {
+		_ = c.stopInstance()
 		return err
 	}
-	instance, err := c.backend.Open(fullPath, c.output)
+	c.mu.Lock()
+	c.asset, c.loop, c.paused = req.AssetPath, req.Loop, false
+	c.positionMs, c.positionAt = req.StartMs, time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+// crossfadeTo starts fullPath on the idle deck and blends it in over
+// req.CrossfadeMs while the current deck fades out, then releases the
+// current deck.
+func (c *channel) crossfadeTo(fullPath string, req PlayRequest, opener DeckOpener) error {
+	from := c.level()
+	c.stopFade()
+	instance, deck, err := c.cueDeck(fullPath, req, opener)
 	if err != nil {
 		return err
 	}
-	c.instance = instance
+	return c.switchDeck(instance, deck, req, from)
+}
+
+// cueDeck opens fullPath silent and transparent on the idle deck.
+func (c *channel) cueDeck(fullPath string, req PlayRequest, opener DeckOpener) (BackendInstance, int, error) {
+	c.mu.Lock()
+	deck := 1 - c.deck
+	c.mu.Unlock()
+	instance, err := opener.OpenDeck(fullPath, c.output, deck)
+	if err != nil {
+		return nil, 0, err
+	}
+	fail := func(err error) (BackendInstance, int, error) {
+		_ = instance.Stop()
+		_ = instance.Close()
+		return nil, 0, err
+	}
 	if err := instance.SetLoop(req.Loop); err != nil {
-		_ = c.stopInstance()
+		return fail(err)
+	}
+	if req.StartMs > 0 {
+		if err := instance.Seek(req.StartMs); err != nil {
+			return fail(err)
+		}
+	}
+	if err := instance.SetVolume(0); err != nil {
+		return fail(err)
+	}
+	if setter, ok := instance.(OpacitySetter); ok {
+		_ = setter.SetOpacity(0)
+	}
+	return instance, deck, nil
+}
+
+// switchDeck plays instance, cued on deck, in front of the current media
+// and releases the current media: over req.CrossfadeMs from volume from
+// when set, at once otherwise.
+func (c *channel) switchDeck(instance BackendInstance, deck int, req PlayRequest, from float64) error {
+	c.stopFade()
+	if err := instance.Play(); err != nil {
+		_ = instance.Stop()
+		_ = instance.Close()
 		return err
 	}
+	if raiser, ok := instance.(Raiser); ok {
+		_ = raiser.Raise()
+	}
 	if req.Volume != nil {
 		c.volume = clampVolume(*req.Volume)
-		if err := instance.SetVolume(c.volume); err != nil {
-			_ = c.stopInstance()
+	} else if req.FadeInMs > 0 && req.CrossfadeMs <= 0 {
+		c.volume = 1.0
+	}
+	c.mu.Lock()
+	xf := &crossfade{outgoing: c.instance, incoming: instance, asset: c.asset, target: c.volume}
+	c.instance, c.deck, c.prepared = instance, deck, nil
+	c.asset, c.loop, c.paused = req.AssetPath, req.Loop, false
+	c.positionMs, c.positionAt = req.StartMs, time.Now()
+	if req.CrossfadeMs > 0 {
+		c.xfade = xf
+	}
+	c.mu.Unlock()
+	if req.CrossfadeMs > 0 {
+		c.startCrossfade(xf, from, req.CrossfadeMs, req.FadeCurve)
+		return nil
+	}
+	xf.finish()
+	if req.FadeInMs > 0 {
+		_ = instance.SetVolume(0)
+		c.startFade(0, c.volume, req.FadeInMs, req.FadeCurve, false)
+	}
+	return nil
+}
+
+// schedule cues fullPath to start after wait. An output that is playing
+// keeps playing until then when the backend has a second deck.
+func (c *channel) schedule(fullPath string, req PlayRequest, wait time.Duration) error {
+	sched := &scheduledStart{path: fullPath, req: req, at: req.StartAt}
+	sched.req.StartAt = time.Time{}
+	opener, decks := c.backend.(DeckOpener)
+	switch {
+	case c.prepared != nil && c.prepared.path == fullPath && c.instance != nil:
+		// Already cued in place, as by a sync group's prepare.
+	case decks && c.instance != nil && c.prepared == nil:
+		c.mu.Lock()
+		crossfading := c.xfade != nil
+		c.mu.Unlock()
+		if crossfading {
+			c.stopFade()
+		}
+		instance, deck, err := c.cueDeck(fullPath, req, opener)
+		if err != nil {
+			return err
+		}
+		if preparer, ok := instance.(Preparer); ok {
+			if err := preparer.Prepare(); err != nil {
+				_ = instance.Stop()
+				_ = instance.Close()
+				return err
+			}
+		}
+		sched.cue, sched.deck = instance, deck
+	default:
+		if err := c.handlePrepare(req); err != nil {
 			return err
 		}
 	}
-	if req.StartMs > 0 {
-		if err := instance.Seek(req.StartMs); err != nil {
+	c.mu.Lock()
+	c.scheduled = sched
+	c.mu.Unlock()
+	sched.timer = time.AfterFunc(wait, func() {
+		c.commands <- &startCmd{sched: sched, resp: make(chan error, 1)}
+	})
+	return nil
+}
+
+// handleStart starts scheduled media, unless another command has
+// cancelled it since the timer fired.
+func (c *channel) handleStart(sched *scheduledStart) error {
+	c.mu.Lock()
+	current := c.scheduled == sched
+	if current {
+		c.scheduled = nil
+	}
+	c.mu.Unlock()
+	if !current {
+		return nil
+	}
+	if sched.cue != nil {
+		return c.switchDeck(sched.cue, sched.deck, sched.req, c.level())
+	}
+	return c.handlePlay(sched.req)
+}
+
+// cancelScheduled drops a pending scheduled start and releases its deck.
+// Media cued in place stays cued.
+func (c *channel) cancelScheduled() {
+	c.mu.Lock()
+	sched := c.scheduled
+	c.scheduled = nil
+	c.mu.Unlock()
+	if sched == nil {
+		return
+	}
+	sched.timer.Stop()
+	if sched.cue != nil {
+		_ = sched.cue.Stop()
+		_ = sched.cue.Close()
+	}
+}
+
+// handlePrepare opens media paused on its first frame, so a later play of
+// the same asset only has to unpause it.
+func (c *channel) handlePrepare(req PlayRequest) error {
+	if req.AssetPath == "" {
+		return errors.New("asset_path required")
+	}
+	fullPath, err := validateAssetPath(c.assetsDir, req.AssetPath)
+	if err != nil {
+		return err
+	}
+	if req.CrossfadeMs > 0 && c.instance != nil && c.prepared == nil {
+		if opener, ok := c.backend.(DeckOpener); ok {
+			return c.cueCrossfade(fullPath, req, opener)
+		}
+	}
+	instance, err := c.open(fullPath, req)
+	if err != nil {
+		return err
+	}
+	if preparer, ok := instance.(Preparer); ok {
+		if err := preparer.Prepare(); err != nil {
 			_ = c.stopInstance()
 			return err
 		}
 	}
+	c.mu.Lock()
+	c.prepared = &preparedMedia{path: fullPath, loop: req.Loop, startMs: req.StartMs}
+	c.asset, c.loop, c.paused = req.AssetPath, req.Loop, false
+	c.positionMs, c.positionAt = req.StartMs, time.Time{}
+	c.mu.Unlock()
+	return nil
+}
+
+// cueCrossfade cues fullPath on the idle deck, leaving the current media
+// playing until a play of fullPath crossfades to it.
+func (c *channel) cueCrossfade(fullPath string, req PlayRequest, opener DeckOpener) error {
+	c.mu.Lock()
+	crossfading := c.xfade != nil
+	c.mu.Unlock()
+	if crossfading {
+		c.stopFade()
+	}
+	instance, deck, err := c.cueDeck(fullPath, req, opener)
+	if err != nil {
+		return err
+	}
+	if preparer, ok := instance.(Preparer); ok {
+		if err := preparer.Prepare(); err != nil {
+			_ = instance.Stop()
+			_ = instance.Close()
+			return err
+		}
+	}
+	c.cued = &deckCue{path: fullPath, instance: instance, deck: deck}
+	return nil
+}
+
+func (c *channel) releaseCue() {
+	if c.cued != nil {
+		c.cued.release()
+		c.cued = nil
+	}
+}
+
+// startPrepared plays prepared media, applying only what the play request
+// changes.
+func (c *channel) startPrepared(req PlayRequest) error {
+	instance := c.instance
+	prepared := c.prepared
+	fail := func(err error) error {
+		_ = c.stopInstance()
+		return err
+	}
+	if req.Loop != prepared.loop {
+		if err := instance.SetLoop(req.Loop); err != nil {
+			return fail(err)
+		}
+	}
+	if req.Volume != nil {
+		c.volume = clampVolume(*req.Volume)
+		if err := instance.SetVolume(c.volume); err != nil {
+			return fail(err)
+		}
+	}
+	if req.StartMs != prepared.startMs {
+		if err := instance.Seek(req.StartMs); err != nil {
+			return fail(err)
+		}
+	}
 	if req.FadeInMs > 0 {
 		target := c.volume
 		if req.Volume == nil {
@@ -358,15 +958,71 @@ func (c *channel) handlePlay(req PlayRequest) error {
 			c.volume = target
 		}
 		_ = instance.SetVolume(0)
-		c.startFade(0, target, req.FadeInMs)
+		c.startFade(0, target, req.FadeInMs, req.FadeCurve, false)
 	}
 	if err := instance.Play(); err != nil {
-		_ = c.stopInstance()
-		return err
+		return fail(err)
 	}
+	c.mu.Lock()
+	c.prepared = nil
+	c.loop, c.paused = req.Loop, false
+	c.positionMs, c.positionAt = req.StartMs, time.Now()
+	c.mu.Unlock()
 	return nil
 }
 
+// open replaces the channel's media with fullPath, configured from req but
+// not yet playing.
+func (c *channel) open(fullPath string, req PlayRequest) (BackendInstance, error) {
+	// The previous media is released only after the new one is open, so
+	// backends that reuse one player per output can swap without a gap;
+	// they ignore Stop on the replaced instance.
+	c.stopFade()
+	previous := c.instance
+	c.setInstance(nil)
+	instance, err := c.openDeck(fullPath)
+	if previous != nil {
+		_ = previous.Stop()
+		_ = previous.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.setInstance(instance)
+	if err := instance.SetLoop(req.Loop); err != nil {
+		_ = c.stopInstance()
+		return nil, err
+	}
+	// The volume is always set, since players kept between media keep
+	// the level a fade-out left them at.
+	if req.Volume != nil {
+		c.volume = clampVolume(*req.Volume)
+	}
+	if err := instance.SetVolume(c.volume); err != nil {
+		_ = c.stopInstance()
+		return nil, err
+	}
+	if req.StartMs > 0 {
+		if err := instance.Seek(req.StartMs); err != nil {
+			_ = c.stopInstance()
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// openDeck opens fullPath on the deck the channel is on, so backends with
+// a player per deck reuse the one already in front.
+func (c *channel) openDeck(fullPath string) (BackendInstance, error) {
+	if opener, ok := c.backend.(DeckOpener); ok {
+		c.mu.Lock()
+		deck := c.deck
+		c.mu.Unlock()
+		return opener.OpenDeck(fullPath, c.output, deck)
+	}
+	return c.backend.Open(fullPath, c.output)
+}
+
 func (c *channel) handleStop() error {
 	return c.stopInstance()
 }
@@ -375,14 +1031,37 @@ func (c *channel) handlePause() error {
 	if c.instance == nil {
 		return errors.New("no active media")
 	}
-	return c.instance.Pause()
+	// Pausing halfway through a crossfade would leave the outgoing media
+	// playing, so the crossfade completes first.
+	c.mu.Lock()
+	crossfading := c.xfade != nil
+	c.mu.Unlock()
+	if crossfading {
+		c.stopFade()
+	}
+	if err := c.instance.Pause(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.positionMs, c.paused = c.positionLocked(time.Now()), true
+	c.mu.Unlock()
+	return nil
 }
 
 func (c *channel) handleResume() error {
 	if c.instance == nil {
 		return errors.New("no active media")
 	}
-	return c.instance.Resume()
+	if prepared := c.prepared; prepared != nil {
+		return c.startPrepared(PlayRequest{Loop: prepared.loop, StartMs: prepared.startMs})
+	}
+	if err := c.instance.Resume(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.paused, c.positionAt = false, time.Now()
+	c.mu.Unlock()
+	return nil
 }
 
 func (c *channel) handleSetVolume(volume float64) error {
@@ -393,7 +1072,7 @@ func (c *channel) handleSetVolume(volume float64) error {
 	return c.instance.SetVolume(c.volume)
 }
 
-func (c *channel) handleFade(target float64, durationMs int) error {
+func (c *channel) handleFade(target float64, durationMs int, curve FadeCurve) error {
 	if c.instance == nil {
 		return errors.New("no active media")
 	}
@@ -401,15 +1080,42 @@ func (c *channel) handleFade(target float64, durationMs int) error {
 		c.volume = clampVolume(target)
 		return c.instance.SetVolume(c.volume)
 	}
-	c.startFade(c.volume, clampVolume(target), durationMs)
+	c.startFade(c.level(), clampVolume(target), durationMs, curve, false)
+	return nil
+}
+
+// handleFadeOut fades to silence and stops once the fade completes.
+// Paused or cued media is silent already and stops at once. The channel
+// volume is left as it was, for the next media.
+func (c *channel) handleFadeOut(durationMs int, curve FadeCurve) error {
+	c.mu.Lock()
+	silent := c.paused || c.prepared != nil
+	c.mu.Unlock()
+	if c.instance == nil || durationMs <= 0 || silent {
+		return c.stopInstance()
+	}
+	c.startFade(c.level(), 0, durationMs, curve, true)
 	return nil
 }
 
+func (c *channel) handleFadeStop(instance BackendInstance) error {
+	if c.instance != instance {
+		return nil
+	}
+	return c.stopInstance()
+}
+
 func (c *channel) handleSeek(positionMs int) error {
 	if c.instance == nil {
 		return errors.New("no active media")
 	}
-	return c.instance.Seek(positionMs)
+	if err := c.instance.Seek(positionMs); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.positionMs, c.positionAt = positionMs, time.Now()
+	c.mu.Unlock()
+	return nil
 }
 
 func (c *channel) stopInstance() error {
@@ -419,38 +1125,144 @@ func (c *channel) stopInstance() error {
 	}
 	_ = c.instance.Stop()
 	err := c.instance.Close()
-	c.instance = nil
+	c.setInstance(nil)
 	return err
 }
 
-func (c *channel) startFade(from, to float64, durationMs int) {
+func (c *channel) setInstance(instance BackendInstance) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instance = instance
+	c.prepared = nil
+	if instance == nil {
+		c.asset, c.loop, c.paused, c.positionMs = "", false, false, 0
+	}
+}
+
+func (c *channel) recordError(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastError, c.lastErrorAt = message, time.Now()
+}
+
+// positionLocked estimates the position from the commands sent, for
+// backends that cannot report it.
+func (c *channel) positionLocked(now time.Time) int {
+	if c.paused || c.positionAt.IsZero() {
+		return c.positionMs
+	}
+	return c.positionMs + int(now.Sub(c.positionAt)/time.Millisecond)
+}
+
+// startFade moves the volume from from to to along curve, in fadeStepMs
+// steps. With stopAfter the media is stopped once the fade completes.
+func (c *channel) startFade(from, to float64, durationMs int, curve FadeCurve, stopAfter bool) {
 	c.stopFade()
+	if curve == "" {
+		curve = FadeLinear
+	}
 	cancel := make(chan struct{})
 	c.fadeCancel = cancel
-	steps := durationMs / 50
+	fade := &fadeState{
+		from:      from,
+		to:        to,
+		curve:     curve,
+		start:     time.Now(),
+		duration:  time.Duration(durationMs) * time.Millisecond,
+		stopAfter: stopAfter,
+	}
+	c.mu.Lock()
+	c.fade = fade
+	c.mu.Unlock()
+	steps := durationMs / fadeStepMs
 	if steps < 1 {
 		steps = 1
 	}
 	stepDur := time.Duration(durationMs/steps) * time.Millisecond
-	delta := (to - from) / float64(steps)
-	go func(instance BackendInstance, start float64) {
-		value := start
+	go func(instance BackendInstance) {
 		ticker := time.NewTicker(stepDur)
 		defer ticker.Stop()
-		for i := 0; i < steps; i++ {
+		for i := 1; i <= steps; i++ {
 			select {
 			case <-cancel:
 				return
 			case <-ticker.C:
-				value += delta
-				_ = instance.SetVolume(clampVolume(value))
+				progress := float64(i) / float64(steps)
+				_ = instance.SetVolume(clampVolume(curve.level(from, to, progress)))
 			}
 		}
-		_ = instance.SetVolume(clampVolume(to))
 		c.mu.Lock()
-		c.volume = clampVolume(to)
+		current := c.fade == fade
+		if current {
+			c.fade = nil
+			if !stopAfter {
+				c.volume = clampVolume(to)
+			}
+		}
 		c.mu.Unlock()
-	}(c.instance, from)
+		if current && stopAfter {
+			c.commands <- &fadeStopCmd{instance: instance, resp: make(chan error, 1)}
+		}
+	}(c.instance)
+}
+
+// startCrossfade ramps the incoming deck up to xf.target and the outgoing
+// deck down from from, then releases the outgoing deck.
+func (c *channel) startCrossfade(xf *crossfade, from float64, durationMs int, curve FadeCurve) {
+	if curve == "" {
+		curve = FadeLinear
+	}
+	cancel := make(chan struct{})
+	c.fadeCancel = cancel
+	fade := &fadeState{
+		to:       xf.target,
+		curve:    curve,
+		start:    time.Now(),
+		duration: time.Duration(durationMs) * time.Millisecond,
+	}
+	c.mu.Lock()
+	c.fade = fade
+	c.mu.Unlock()
+	steps := durationMs / fadeStepMs
+	if steps < 1 {
+		steps = 1
+	}
+	stepDur := time.Duration(durationMs/steps) * time.Millisecond
+	setter, _ := xf.incoming.(OpacitySetter)
+	go func() {
+		ticker := time.NewTicker(stepDur)
+		defer ticker.Stop()
+		for i := 1; i < steps; i++ {
+			select {
+			case <-cancel:
+				return
+			case <-ticker.C:
+				progress := float64(i) / float64(steps)
+				_ = xf.outgoing.SetVolume(clampVolume(curve.level(from, 0, progress)))
+				_ = xf.incoming.SetVolume(clampVolume(curve.level(0, xf.target, progress)))
+				if setter != nil {
+					_ = setter.SetOpacity(progress)
+				}
+			}
+		}
+		select {
+		case <-cancel:
+			return
+		case <-ticker.C:
+		}
+		c.mu.Lock()
+		current := c.xfade == xf
+		if current {
+			c.xfade = nil
+		}
+		if c.fade == fade {
+			c.fade = nil
+		}
+		c.mu.Unlock()
+		if current {
+			xf.finish()
+		}
+	}()
 }
 
 func (c *channel) stopFade() {
@@ -458,16 +1270,116 @@ func (c *channel) stopFade() {
 		close(c.fadeCancel)
 		c.fadeCancel = nil
 	}
+	// A crossfade cut short releases the outgoing deck and leaves the
+	// incoming one at its target volume.
+	c.mu.Lock()
+	xf := c.xfade
+	c.xfade, c.fade = nil, nil
+	c.mu.Unlock()
+	if xf != nil {
+		xf.finish()
+	}
 }
 
-func (c *channel) snapshot() map[string]any {
+// level is the volume the output is at, partway through any fade.
+func (c *channel) level() float64 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return map[string]any{
-		"output": c.output,
-		"active": c.instance != nil,
-		"volume": c.volume,
+	if c.fade != nil {
+		return c.fade.level(time.Now())
+	}
+	return c.volume
+}
+
+func (xf *crossfade) finish() {
+	// The outgoing media may have stopped on its own, as at the end of a
+	// fade-out, before a scheduled switch.
+	if xf.outgoing != nil {
+		_ = xf.outgoing.Stop()
+		_ = xf.outgoing.Close()
+	}
+	_ = xf.incoming.SetVolume(xf.target)
+	if setter, ok := xf.incoming.(OpacitySetter); ok {
+		_ = setter.SetOpacity(1)
+	}
+}
+
+func (c *channel) status() types.PlaybackStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := types.PlaybackStatus{
+		OutputID:  c.output.ID,
+		Active:    c.instance != nil,
+		Volume:    c.volume,
+		LastError: c.lastError,
+	}
+	if !c.lastErrorAt.IsZero() {
+		at := c.lastErrorAt.UTC()
+		status.LastErrorAt = &at
+	}
+	if c.scheduled != nil {
+		at := c.scheduled.at.UTC()
+		status.StartsAt = &at
+	}
+	if c.instance == nil {
+		return status
+	}
+	status.Asset, status.Loop, status.Paused = c.asset, c.loop, c.paused
+	status.Prepared = c.prepared != nil
+	status.Playing = !c.paused && c.prepared == nil
+	status.PositionMs = c.positionLocked(time.Now())
+	if reporter, ok := c.instance.(StatusReporter); ok {
+		if media, ok := reporter.Status(); ok {
+			status.Playing = media.Playing && !c.paused && c.prepared == nil
+			status.PositionMs = media.PositionMs
+			status.DurationMs = media.DurationMs
+		}
+	}
+	return status
+}
+
+func (c *channel) snapshot() map[string]any {
+	status := c.status()
+	snapshot := map[string]any{
+		"output":      c.output,
+		"active":      status.Active,
+		"volume":      status.Volume,
+		"asset":       status.Asset,
+		"playing":     status.Playing,
+		"paused":      status.Paused,
+		"prepared":    status.Prepared,
+		"loop":        status.Loop,
+		"position_ms": status.PositionMs,
+		"duration_ms": status.DurationMs,
+	}
+	c.mu.Lock()
+	snapshot["deck"] = c.deck
+	if c.xfade != nil {
+		snapshot["crossfade_from"] = c.xfade.asset
+	}
+	if sched := c.scheduled; sched != nil {
+		snapshot["scheduled"] = map[string]any{
+			"asset":     sched.req.AssetPath,
+			"starts_at": sched.at.UTC(),
+			"deck":      sched.cue != nil,
+		}
+	}
+	if fade := c.fade; fade != nil {
+		now := time.Now()
+		snapshot["fade"] = map[string]any{
+			"level":        fade.level(now),
+			"target":       fade.to,
+			"curve":        fade.curve,
+			"remaining_ms": int(fade.remaining(now) / time.Millisecond),
+			"stop_after":   fade.stopAfter,
+		}
+	}
+	c.mu.Unlock()
+	if status.LastError != "" {
+		snapshot["last_error"] = status.LastError
+		snapshot["last_error_at"] = status.LastErrorAt
 	}
+	return snapshot
 }
 
 func validateAssetPath(assetsDir, asset string) (string, error) {
@@ -0,0 +1,277 @@
+package playback
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+)
+
+const (
+	DefaultSyncInterval  = 5 * time.Second
+	DefaultSyncTolerance = 100 * time.Millisecond
+)
+
+// SyncItem is one output's part of a synchronized start.
+type SyncItem struct {
+	OutputID string
+	Request  PlayRequest
+}
+
+// syncGroup is a set of outputs started together. The first output leads:
+// drift correction seeks the others to its position.
+type syncGroup struct {
+	name    string
+	outputs []string
+	assets  []string
+	started time.Time
+	stop    chan struct{}
+
+	mu          sync.Mutex
+	corrections int
+	drift       map[string]int
+	checkedAt   time.Time
+}
+
+// PlaySync starts media on several outputs at once. Every output is
+// prepared first, so the start itself is only an unpause on each; with
+// CrossfadeMs, a playing output cues on its idle deck and the start
+// crossfades to it. Outputs with a StartAt cue themselves and start at
+// that time instead. If any output fails to cue or start, the outputs
+// that were cued are stopped. While
+// the media plays, outputs that drift from the first by more than
+// SyncTolerance are seeked back every SyncInterval.
+func (m *Manager) PlaySync(name string, items []SyncItem) error {
+	if len(items) == 0 {
+		return errors.New("sync group has no outputs")
+	}
+	m.mu.Lock()
+	outputs := make([]string, len(items))
+	assets := make([]string, len(items))
+	seen := map[string]bool{}
+	for i, item := range items {
+		resolved, ok := m.resolveOutputLocked(item.OutputID)
+		if !ok {
+			m.mu.Unlock()
+			return fmt.Errorf("output not found: %s", item.OutputID)
+		}
+		if seen[resolved] {
+			m.mu.Unlock()
+			return fmt.Errorf("output %s is in sync group %s twice", resolved, name)
+		}
+		seen[resolved] = true
+		outputs[i], assets[i] = resolved, item.Request.AssetPath
+	}
+	interval, tolerance := m.SyncInterval, m.SyncTolerance
+	// Positions coarser than the tolerance read as drift that a seek
+	// cannot correct, so every round would seek for nothing.
+	if resolver, ok := m.backend.(PositionResolver); ok && interval > 0 {
+		if resolution := resolver.PositionResolution(); resolution > tolerance {
+			log.Printf("sync group %s: drift correction off, positions are only accurate to %s (tolerance %s)", name, resolution, tolerance)
+			interval = 0
+		}
+	}
+	m.mu.Unlock()
+
+	m.endSync(name, outputs)
+	prepared := make([]bool, len(outputs))
+	err := together(outputs, func(i int, outputID string) error {
+		if !items[i].Request.StartAt.IsZero() {
+			return nil
+		}
+		err := m.Prepare(outputID, items[i].Request)
+		prepared[i] = err == nil
+		return err
+	})
+	if err != nil {
+		// Outputs that were cued no longer play what they did before.
+		for i, outputID := range outputs {
+			if prepared[i] {
+				_ = m.Stop(outputID)
+			}
+		}
+		return err
+	}
+	if err := together(outputs, func(i int, outputID string) error {
+		return m.Play(outputID, items[i].Request)
+	}); err != nil {
+		// A group that did not start as a whole is not left half cued.
+		for i, outputID := range outputs {
+			if prepared[i] {
+				_ = m.Stop(outputID)
+			}
+		}
+		return err
+	}
+
+	started := time.Now()
+	if at := items[0].Request.StartAt; at.After(started) {
+		started = at
+	}
+	group := &syncGroup{
+		name:    name,
+		outputs: outputs,
+		assets:  assets,
+		started: started,
+		stop:    make(chan struct{}),
+		drift:   make(map[string]int),
+	}
+	m.mu.Lock()
+	m.groups[name] = group
+	m.mu.Unlock()
+	if interval > 0 && len(outputs) > 1 {
+		go m.correctDrift(group, interval, tolerance)
+	}
+	return nil
+}
+
+// together runs fn for every output at the same moment and waits for all
+// of them.
+func together(outputs []string, fn func(i int, outputID string) error) error {
+	errs := make([]error, len(outputs))
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i, outputID := range outputs {
+		wg.Add(1)
+		go func(i int, outputID string) {
+			defer wg.Done()
+			<-start
+			if err := fn(i, outputID); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", outputID, err)
+			}
+		}(i, outputID)
+	}
+	close(start)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// endSync ends the group called name and any group sharing an output
+// with outputs.
+func (m *Manager) endSync(name string, outputs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for groupName, group := range m.groups {
+		if groupName == name || group.overlaps(outputs) {
+			close(group.stop)
+			delete(m.groups, groupName)
+		}
+	}
+}
+
+func (m *Manager) endGroup(group *syncGroup) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.groups[group.name] == group {
+		close(group.stop)
+		delete(m.groups, group.name)
+	}
+}
+
+func (g *syncGroup) overlaps(outputs []string) bool {
+	for _, a := range g.outputs {
+		for _, b := range outputs {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Manager) correctDrift(group *syncGroup, interval, tolerance time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-group.stop:
+			return
+		case <-ticker.C:
+		}
+		if !m.checkDrift(group, tolerance) {
+			m.endGroup(group)
+			return
+		}
+	}
+}
+
+// checkDrift seeks outputs that have drifted from the leader. It reports
+// false once the group is over: an output stopped or plays other media.
+// Rounds where an output is paused or yet to start are skipped.
+func (m *Manager) checkDrift(group *syncGroup, tolerance time.Duration) bool {
+	chans := make([]*channel, len(group.outputs))
+	m.mu.Lock()
+	for i, outputID := range group.outputs {
+		chans[i] = m.channels[outputID]
+	}
+	m.mu.Unlock()
+	// Statuses may query the backend, so they are read outside m.mu.
+	statuses := make([]types.PlaybackStatus, len(chans))
+	for i, ch := range chans {
+		if ch == nil {
+			return false
+		}
+		statuses[i] = ch.status()
+	}
+	for _, status := range statuses {
+		if status.StartsAt != nil {
+			return true
+		}
+	}
+	for i, status := range statuses {
+		if !status.Active || status.Asset != group.assets[i] {
+			return false
+		}
+		if !status.Playing {
+			return true
+		}
+	}
+	leader := statuses[0]
+	limit := int(tolerance / time.Millisecond)
+	for i := 1; i < len(statuses); i++ {
+		drift := statuses[i].PositionMs - leader.PositionMs
+		if duration := leader.DurationMs; leader.Loop && duration > 0 {
+			// Looping media may be a lap apart; take the nearest lap.
+			drift = ((drift % duration) + duration) % duration
+			if drift > duration/2 {
+				drift -= duration
+			}
+		}
+		outputID := group.outputs[i]
+		corrected := false
+		if drift > limit || drift < -limit {
+			corrected = m.Seek(outputID, leader.PositionMs) == nil
+		}
+		group.mu.Lock()
+		group.drift[outputID] = drift
+		group.checkedAt = time.Now()
+		if corrected {
+			group.corrections++
+		}
+		group.mu.Unlock()
+	}
+	return true
+}
+
+func (g *syncGroup) snapshot() map[string]any {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	drift := make(map[string]int, len(g.drift))
+	for outputID, ms := range g.drift {
+		drift[outputID] = ms
+	}
+	snapshot := map[string]any{
+		"outputs":     g.outputs,
+		"started_at":  g.started.UTC(),
+		"corrections": g.corrections,
+		"drift_ms":    drift,
+	}
+	if !g.checkedAt.IsZero() {
+		snapshot["checked_at"] = g.checkedAt.UTC()
+	}
+	return snapshot
+}
+
@@ -0,0 +1,496 @@
+package plugins
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"deployable/internal/actions"
+	"deployable/internal/sensors"
+	"deployable/internal/types"
+)
+
+const (
+	ProtocolVersion = 1
+
+	initializeTimeout = 10 * time.Second
+	executeTimeout    = 30 * time.Second
+	maxRestartBackoff = 30 * time.Second
+	stableRunDuration = time.Minute
+)
+
+type Registrar interface {
+	Register(exec actions.ActionExecutor) error
+	Unregister(name string)
+}
+
+type SensorAnnouncement struct {
+	ID           string         `json:"id"`
+	Type         string         `json:"type"`
+	Capabilities map[string]any `json:"capabilities,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	DeviceID        string `json:"device_id"`
+}
+
+type initializeResult struct {
+	Name    string               `json:"name"`
+	Actions []string             `json:"actions"`
+	Sensors []SensorAnnouncement `json:"sensors"`
+}
+
+type executeParams struct {
+	Action string         `json:"action"`
+	Target string         `json:"target"`
+	Params map[string]any `json:"params"`
+}
+
+type sensorEventParams struct {
+	SensorID  string    `json:"sensor_id"`
+	EventType string    `json:"event_type"`
+	Value     any       `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Host launches every executable in the plugins directory, registers the
+// actions and sensors each one announces, and restarts plugins that exit.
+type Host struct {
+	Dir       string
+	Registrar Registrar
+	Sensors   *sensors.Manager
+
+	mu      sync.Mutex
+	plugins []*plugin
+	stop    chan struct{}
+}
+
+func (h *Host) Start(deviceID string) error {
+	paths, err := discover(h.Dir)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stop = make(chan struct{})
+	for _, path := range paths {
+		p := &plugin{
+			host:     h,
+			name:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+			path:     path,
+			actions:  map[string]bool{},
+			deviceID: deviceID,
+			ready:    make(chan struct{}),
+		}
+		h.plugins = append(h.plugins, p)
+		go p.supervise(h.stop)
+	}
+	if len(paths) > 0 {
+		log.Printf("plugins: launching %d from %s", len(paths), h.Dir)
+	}
+	return nil
+}
+
+// WaitReady blocks until every plugin has answered initialize or failed
+// to, so the actions they announce are registered before show logic that
+// uses them is validated. It gives up after the initialize timeout.
+func (h *Host) WaitReady() {
+	h.mu.Lock()
+	plugins := h.plugins
+	h.mu.Unlock()
+	deadline := time.After(initializeTimeout)
+	for _, p := range plugins {
+		select {
+		case <-p.ready:
+		case <-deadline:
+			log.Printf("plugins: %s not initialized in time", p.name)
+			return
+		}
+	}
+}
+
+func (h *Host) Stop() {
+	h.mu.Lock()
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+	plugins := h.plugins
+	h.mu.Unlock()
+	for _, p := range plugins {
+		p.shutdown()
+	}
+}
+
+func (h *Host) Snapshot() []map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]map[string]any, 0, len(h.plugins))
+	for _, p := range h.plugins {
+		out = append(out, p.snapshot())
+	}
+	return out
+}
+
+func discover(dir string) ([]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			if !strings.EqualFold(filepath.Ext(entry.Name()), ".exe") {
+				continue
+			}
+		} else if info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+type plugin struct {
+	host     *Host
+	name     string
+	path     string
+	deviceID string
+
+	mu        sync.Mutex
+	proc      *process
+	info      initializeResult
+	actions   map[string]bool
+	sensors   []*pluginSensor
+	running   bool
+	restarts  int
+	lastError string
+	startedAt time.Time
+	stopped   bool
+
+	// ready is closed once the first launch has initialized or failed.
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func (p *plugin) markReady() {
+	p.readyOnce.Do(func() { close(p.ready) })
+}
+
+func (p *plugin) supervise(stop <-chan struct{}) {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := p.runOnce()
+		p.markReady()
+		p.mu.Lock()
+		p.running = false
+		p.proc = nil
+		if err != nil {
+			p.lastError = err.Error()
+		}
+		stopped := p.stopped
+		p.mu.Unlock()
+		if stopped {
+			return
+		}
+		log.Printf("plugin %s stopped: %v", p.name, err)
+		if time.Since(started) > stableRunDuration {
+			backoff = time.Second
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < maxRestartBackoff {
+			backoff *= 2
+		}
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+	}
+}
+
+func (p *plugin) runOnce() error {
+	proc, err := startProcess(p.name, p.path, p.handleNotification)
+	if err != nil {
+		return err
+	}
+	var info initializeResult
+	if err := proc.call("initialize", initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		DeviceID:        p.deviceID,
+	}, initializeTimeout, &info); err != nil {
+		proc.stop()
+		return err
+	}
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		proc.stop()
+		return nil
+	}
+	p.proc = proc
+	p.running = true
+	p.startedAt = time.Now().UTC()
+	p.lastError = ""
+	p.mu.Unlock()
+
+	if rebuilt := p.applyAnnouncement(info); !rebuilt {
+		p.mu.Lock()
+		active := []*pluginSensor{}
+		for _, sensor := range p.sensors {
+			if sensor.started() {
+				active = append(active, sensor)
+			}
+		}
+		p.mu.Unlock()
+		for _, sensor := range active {
+			_ = proc.notify("sensor.start", map[string]string{"sensor_id": sensor.announcement.ID})
+		}
+	}
+	p.markReady()
+	<-proc.done
+	return proc.exitError()
+}
+
+// applyAnnouncement reconciles registered actions and sensors with what the
+// plugin announced. Sensors are only rebuilt (and started by the manager)
+// when the announcement changes; otherwise the caller re-sends sensor.start
+// for the sensors that were already running.
+func (p *plugin) applyAnnouncement(info initializeResult) bool {
+	p.mu.Lock()
+	previous := p.info
+	p.info = info
+	announced := map[string]bool{}
+	for _, name := range info.Actions {
+		announced[name] = true
+	}
+	var toRegister, toRemove []string
+	for name := range announced {
+		if !p.actions[name] {
+			toRegister = append(toRegister, name)
+		}
+	}
+	for name := range p.actions {
+		if !announced[name] {
+			toRemove = append(toRemove, name)
+			delete(p.actions, name)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, name := range toRemove {
+		p.host.Registrar.Unregister(name)
+	}
+	for _, name := range toRegister {
+		if err := p.host.Registrar.Register(pluginExecutor{plugin: p, action: name}); err != nil {
+			log.Printf("plugin %s: action %s not registered: %v", p.name, name, err)
+			continue
+		}
+		p.mu.Lock()
+		p.actions[name] = true
+		p.mu.Unlock()
+	}
+
+	if p.host.Sensors == nil || reflect.DeepEqual(previous.Sensors, info.Sensors) {
+		return false
+	}
+	list := make([]sensors.Sensor, 0, len(info.Sensors))
+	proxies := make([]*pluginSensor, 0, len(info.Sensors))
+	for _, announcement := range info.Sensors {
+		if announcement.ID == "" {
+			continue
+		}
+		proxy := &pluginSensor{plugin: p, announcement: announcement}
+		proxies = append(proxies, proxy)
+		list = append(list, proxy)
+	}
+	p.mu.Lock()
+	p.sensors = proxies
+	p.mu.Unlock()
+	p.host.Sensors.SetGroup("plugin:"+p.name, list)
+	return true
+}
+
+func (p *plugin) handleNotification(method string, params json.RawMessage) {
+	if method != "sensor_event" {
+		return
+	}
+	var event sensorEventParams
+	if err := json.Unmarshal(params, &event); err != nil {
+		log.Printf("plugin %s: invalid sensor_event: %v", p.name, err)
+		return
+	}
+	p.mu.Lock()
+	var target *pluginSensor
+	for _, sensor := range p.sensors {
+		if sensor.announcement.ID == event.SensorID {
+			target = sensor
+			break
+		}
+	}
+	p.mu.Unlock()
+	if target == nil {
+		log.Printf("plugin %s: event for unannounced sensor %s", p.name, event.SensorID)
+		return
+	}
+	target.emit(event)
+}
+
+func (p *plugin) execute(action, target string, params map[string]any) error {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+	if proc == nil {
+		return errors.New("plugin " + p.name + " is not running")
+	}
+	return proc.call("execute", executeParams{Action: action, Target: target, Params: params}, executeTimeout, nil)
+}
+
+func (p *plugin) notify(method string, params any) {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+	if proc != nil {
+		_ = proc.notify(method, params)
+	}
+}
+
+func (p *plugin) shutdown() {
+	p.mu.Lock()
+	p.stopped = true
+	proc := p.proc
+	p.mu.Unlock()
+	if proc != nil {
+		proc.stop()
+	}
+}
+
+func (p *plugin) snapshot() map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	registered := make([]string, 0, len(p.actions))
+	for name := range p.actions {
+		registered = append(registered, name)
+	}
+	sort.Strings(registered)
+	return map[string]any{
+		"name":       p.name,
+		"path":       p.path,
+		"running":    p.running,
+		"restarts":   p.restarts,
+		"last_error": p.lastError,
+		"started_at": p.startedAt,
+		"actions":    registered,
+		"sensors":    p.info.Sensors,
+	}
+}
+
+type pluginExecutor struct {
+	plugin *plugin
+	action string
+}
+
+func (e pluginExecutor) ActionName() string {
+	return e.action
+}
+
+func (e pluginExecutor) Blocking() bool {
+	return true
+}
+
+func (e pluginExecutor) Execute(target string, params map[string]any) error {
+	return e.plugin.execute(e.action, target, params)
+}
+
+type pluginSensor struct {
+	plugin       *plugin
+	announcement SensorAnnouncement
+
+	mu   sync.Mutex
+	sink chan<- types.SensorEvent
+}
+
+func (s *pluginSensor) ID() string {
+	return s.announcement.ID
+}
+
+func (s *pluginSensor) Type() string {
+	if s.announcement.Type == "" {
+		return "plugin"
+	}
+	return s.announcement.Type
+}
+
+func (s *pluginSensor) Capabilities() map[string]any {
+	caps := map[string]any{"plugin": s.plugin.name}
+	for key, value := range s.announcement.Capabilities {
+		caps[key] = value
+	}
+	return caps
+}
+
+func (s *pluginSensor) Start(eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	s.sink = eventSink
+	s.mu.Unlock()
+	s.plugin.notify("sensor.start", map[string]string{"sensor_id": s.announcement.ID})
+}
+
+func (s *pluginSensor) Stop() {
+	s.mu.Lock()
+	s.sink = nil
+	s.mu.Unlock()
+	s.plugin.notify("sensor.stop", map[string]string{"sensor_id": s.announcement.ID})
+}
+
+func (s *pluginSensor) started() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sink != nil
+}
+
+func (s *pluginSensor) emit(event sensorEventParams) {
+	s.mu.Lock()
+	sink := s.sink
+	s.mu.Unlock()
+	if sink == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	sink <- types.SensorEvent{
+		SensorID:   s.announcement.ID,
+		SensorType: s.Type(),
+		EventType:  event.EventType,
+		Value:      event.Value,
+		Timestamp:  event.Timestamp,
+	}
+}
+
@@ -0,0 +1,239 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	maxMessageSize = 4 << 20
+	// notificationQueue is how many plugin notifications may wait for the
+	// host before further ones are dropped.
+	notificationQueue = 256
+)
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// process is one running plugin binary speaking newline-delimited
+// JSON-RPC 2.0 on its stdin and stdout.
+type process struct {
+	name string
+	cmd  *exec.Cmd
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcMessage
+	done    chan struct{}
+	exitErr error
+}
+
+func startProcess(name, path string, onNotify func(method string, params json.RawMessage)) (*process, error) {
+	cmd := exec.Command(path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = &stderrLogger{name: name}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &process{
+		name:    name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcMessage),
+		done:    make(chan struct{}),
+	}
+	go p.readLoop(stdout, onNotify)
+	return p, nil
+}
+
+func (p *process) call(method string, params any, timeout time.Duration, result any) error {
+	p.mu.Lock()
+	if p.exitErr != nil {
+		p.mu.Unlock()
+		return p.exitErr
+	}
+	p.nextID++
+	id := p.nextID
+	resp := make(chan rpcMessage, 1)
+	p.pending[id] = resp
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	if err := p.send(rpcMessage{ID: &id, Method: method}, params); err != nil {
+		return err
+	}
+	select {
+	case msg := <-resp:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	case <-p.done:
+		return p.exitError()
+	case <-time.After(timeout):
+		return fmt.Errorf("plugin %s: %s timed out", p.name, method)
+	}
+}
+
+func (p *process) notify(method string, params any) error {
+	return p.send(rpcMessage{Method: method}, params)
+}
+
+func (p *process) send(msg rpcMessage, params any) error {
+	msg.JSONRPC = "2.0"
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		msg.Params = data
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	_, err = p.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// readLoop routes responses to their callers. Notifications are handled on
+// a separate goroutine, so a slow handler cannot hold up responses.
+func (p *process) readLoop(stdout io.Reader, onNotify func(method string, params json.RawMessage)) {
+	notifications := make(chan rpcMessage, notificationQueue)
+	go func() {
+		for msg := range notifications {
+			onNotify(msg.Method, msg.Params)
+		}
+	}()
+	defer close(notifications)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageSize)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Printf("plugin %s: invalid message: %v", p.name, err)
+			continue
+		}
+		if msg.Method != "" {
+			if msg.ID != nil {
+				id := *msg.ID
+				_ = p.send(rpcMessage{ID: &id, Error: &rpcError{Code: -32601, Message: "host does not accept requests"}}, nil)
+				continue
+			}
+			select {
+			case notifications <- msg:
+			default:
+				log.Printf("plugin %s: notifications backed up, dropped %s", p.name, msg.Method)
+			}
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+		p.mu.Lock()
+		resp := p.pending[*msg.ID]
+		p.mu.Unlock()
+		if resp != nil {
+			resp <- msg
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// The plugin can no longer be heard, so it is restarted rather
+		// than left running.
+		log.Printf("plugin %s: read failed: %v", p.name, err)
+		if p.cmd.Process != nil {
+			_ = p.cmd.Process.Kill()
+		}
+	}
+	err := p.cmd.Wait()
+	if err == nil {
+		err = errors.New("exited")
+	}
+	p.mu.Lock()
+	p.exitErr = fmt.Errorf("plugin %s: %w", p.name, err)
+	p.mu.Unlock()
+	close(p.done)
+}
+
+type stderrLogger struct {
+	name string
+	buf  []byte
+}
+
+func (l *stderrLogger) Write(data []byte) (int, error) {
+	l.buf = append(l.buf, data...)
+	for {
+		idx := bytes.IndexByte(l.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		log.Printf("plugin %s: %s", l.name, bytes.TrimRight(l.buf[:idx], "\r"))
+		l.buf = l.buf[idx+1:]
+	}
+	if len(l.buf) > maxMessageSize {
+		l.buf = l.buf[:0]
+	}
+	return len(data), nil
+}
+
+func (p *process) exitError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exitErr
+}
+
+func (p *process) stop() {
+	_ = p.notify("shutdown", nil)
+	_ = p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(2 * time.Second):
+		if p.cmd.Process != nil {
+			_ = p.cmd.Process.Kill()
+		}
+		<-p.done
+	}
+}
+
@@ -1,20 +1,32 @@
 package runtime
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
 	"deployable/internal/actions"
 	"deployable/internal/assets"
 	"deployable/internal/capabilities"
+	"deployable/internal/clock"
 	"deployable/internal/engine"
+	"deployable/internal/journal"
+	"deployable/internal/mqtt"
+	"deployable/internal/osc"
 	"deployable/internal/playback"
+	"deployable/internal/plugins"
 	"deployable/internal/sensors"
 	"deployable/internal/server"
 	"deployable/internal/storage"
@@ -35,16 +47,58 @@ type Runtime struct {
 	actions chan types.EngineAction
 	sensors *sensors.Manager
 	player  *playback.Manager
+	mqtt    *mqtt.Manager
+	commands *actions.CommandRegistry
+	dispatcher *actions.Dispatcher
+	plugins    *plugins.Host
+	journal    *journal.Recorder
+	recordJournal bool
+	replayMode    bool
+	offline       bool
+	oscSource     *osc.StateSource
 	actionErrors chan actions.DispatchError
 
 	serverIncoming chan server.Incoming
 	serverOutgoing chan any
+	clock          *clock.Estimator
+	syncLead       time.Duration
 
+	// lastState is written on the incoming loop. stateMu guards it for
+	// readers on other goroutines, which use currentState.
+	stateMu        sync.Mutex
 	lastState      types.GlobalStateUpdate
 	lastConnected  time.Time
-	supportedActions map[string]bool
+
+	// sensorTokens is a copy of the profile's tokens for web handlers,
+	// replaced whole when a profile is applied.
+	tokensMu     sync.RWMutex
+	sensorTokens map[string]string
+
+	requestsMu    sync.Mutex
+	stateRequests map[string]*pendingStateRequest
+
+	// votes tracks open votes only. closedVote remembers the last one to
+	// close, so repeated closed tallies for it do not fire vote_closed again.
+	votes      map[string]*voteProgress
+	closedVote string
+}
+
+// VoteSensorID is the sensor ID under which audience vote progress reaches
+// the engine.
+const VoteSensorID = "vote"
+
+type voteProgress struct {
+	leader int
+}
+
+type pendingStateRequest struct {
+	state    string
+	sensorID string
+	timer    *time.Timer
 }
 
+const stateRequestTimeout = 10 * time.Second
+
 type Config struct {
 	DataDir         string
 	AssetsDir       string
@@ -53,6 +107,22 @@ type Config struct {
 	PlaybackBackend string
 	VLCPath         string
 	VLCDebug        bool
+	VLCRestart      playback.RestartPolicy
+	MPVPath         string
+	MPVAudioDevices map[string]string
+	TimelineFile     string
+	TimelineDuration time.Duration
+	SyncInterval    time.Duration
+	SyncTolerance   time.Duration
+	// SyncLead is how long before a state_update's effective_at the
+	// state is entered, so its media can be cued in time.
+	SyncLead        time.Duration
+	AllowedCommands []string
+	AllowedCommandEnv  []string
+	CommandWorkDirRoot string
+	PluginsDir      string
+	Journal         bool
+	ReplayMode      bool
 }
 
 func NewRuntime(cfg Config) *Runtime {
@@ -61,43 +131,104 @@ func NewRuntime(cfg Config) *Runtime {
 	var backend playback.MediaBackend
 	backend = playback.NewStubBackend()
 	if cfg.PlaybackBackend == "vlc" {
-		backend = playback.NewVLCCommandBackendWithDebug(cfg.VLCPath, cfg.VLCDebug)
+		vlc := playback.NewVLCCommandBackendWithDebug(cfg.VLCPath, cfg.VLCDebug)
+		if cfg.VLCRestart.Mode != "" {
+			vlc.Restart = cfg.VLCRestart
+		}
+		backend = vlc
+	} else if cfg.PlaybackBackend == "mpv" {
+		mpv := playback.NewMPVBackend(cfg.MPVPath)
+		mpv.AudioDevices = cfg.MPVAudioDevices
+		backend = mpv
+	} else if cfg.PlaybackBackend == "timeline" {
+		timeline := playback.NewTimelineBackend()
+		timeline.DefaultDuration = cfg.TimelineDuration
+		if cfg.TimelineFile != "" {
+			if err := timeline.RecordTo(cfg.TimelineFile); err != nil {
+				log.Printf("timeline file unavailable: %v", err)
+			}
+		}
+		backend = timeline
 	} else if cfg.PlaybackBackend == "libvlc" {
 		backend = playback.NewVLCBackend()
 	}
 	player := playback.NewManager(cfg.AssetsDir, backend)
+	player.SyncInterval = cfg.SyncInterval
+	if cfg.SyncTolerance > 0 {
+		player.SyncTolerance = cfg.SyncTolerance
+	}
+	serverClock := clock.NewEstimator()
 	actionErrors := make(chan actions.DispatchError, 32)
-	disp := actions.NewDispatcher(actionsChan, []actions.ActionExecutor{
-		actions.PlayVideoExecutor{Player: player},
+	sensorEvents := make(chan types.SensorEvent, 256)
+	mqttManager := mqtt.NewManager()
+	sensorManager := sensors.NewManager(sensorEvents)
+	commands := actions.NewCommandRegistry(cfg.AllowedCommands, cfg.AllowedCommandEnv, cfg.CommandWorkDirRoot)
+	// Result events enter as sensor input, so they count towards sensor
+	// health and feed derived sensors.
+	sideEffects := []actions.ActionExecutor{
+		actions.HTTPRequestExecutor{Client: &http.Client{}, Emit: sensorManager.Inject},
+		actions.MQTTPublishExecutor{Broker: mqttManager},
+		actions.OSCSendExecutor{},
+		actions.CommandExecutor{Commands: commands, Emit: sensorManager.Inject},
+	}
+	if cfg.ReplayMode {
+		for i, exec := range sideEffects {
+			sideEffects[i] = actions.ReplayExecutor{Name: exec.ActionName()}
+		}
+	}
+	disp := actions.NewDispatcher(actionsChan, append([]actions.ActionExecutor{
+		actions.PlayVideoExecutor{Player: player, Clock: serverClock},
 		actions.StopVideoExecutor{Player: player},
-		actions.PlayAudioExecutor{Player: player},
+		actions.PlayAudioExecutor{Player: player, Clock: serverClock},
 		actions.StopAudioExecutor{Player: player},
+		actions.PrepareVideoExecutor{Player: player},
+		actions.PrepareAudioExecutor{Player: player},
 		actions.SetVolumeExecutor{Player: player},
 		actions.StopAllExecutor{Player: player},
 		actions.PauseExecutor{Player: player},
 		actions.ResumeExecutor{Player: player},
 		actions.FadeVolumeExecutor{Player: player},
 		actions.SeekExecutor{Player: player},
-		actions.MediaPlayExecutor{Player: player},
+		actions.PlaySyncExecutor{Player: player, Clock: serverClock},
+		actions.MediaPlayExecutor{Player: player, Clock: serverClock},
+		actions.MediaPrepareExecutor{Player: player},
 		actions.MediaStopExecutor{Player: player},
 		actions.MediaPauseExecutor{Player: player},
 		actions.MediaResumeExecutor{Player: player},
 		actions.MediaSeekExecutor{Player: player},
 		actions.MediaSetExecutor{Player: player},
 		actions.MediaFadeExecutor{Player: player},
-	}, actionErrors)
+	}, sideEffects...), actionErrors)
 	engineInstance := engine.NewEngine()
 	rt := &Runtime{
 		store:   store,
 		syncer:  &assets.Syncer{AssetsDir: cfg.AssetsDir, SourceDir: cfg.AssetsSourceDir, SourceURL: cfg.AssetsSourceURL},
 		engine:  engineInstance,
 		actions: actionsChan,
-		sensors: sensors.NewManager(make(chan types.SensorEvent, 256)),
+		sensors: sensorManager,
 		player:  player,
+		mqtt:    mqttManager,
+		commands: commands,
+		dispatcher: disp,
+		plugins: &plugins.Host{
+			Dir:       cfg.PluginsDir,
+			Registrar: disp,
+			Sensors:   sensorManager,
+		},
+		recordJournal: cfg.Journal && !cfg.ReplayMode,
+		replayMode:    cfg.ReplayMode,
 		actionErrors: actionErrors,
 		serverIncoming: make(chan server.Incoming, 32),
 		serverOutgoing: make(chan any, 32),
-		supportedActions: disp.SupportedActions(),
+		clock:          serverClock,
+		syncLead:       cfg.SyncLead,
+		stateRequests:  make(map[string]*pendingStateRequest),
+		votes:          make(map[string]*voteProgress),
+	}
+	sensorManager.OnHealthChange(rt.reportSensorHealth)
+	player.SetEventHandler(rt.handlePlaybackEvent)
+	if err := disp.Register(actions.StateRequestExecutor{Requests: rt}); err != nil {
+		log.Printf("state.request unavailable: %v", err)
 	}
 	go disp.Run(make(chan struct{}))
 	go rt.forwardActions()
@@ -133,8 +264,20 @@ func (r *Runtime) Boot() error {
 	} else {
 		r.player.ConfigureOutputs(caps.VideoOutputs, caps.AudioOutputs)
 	}
+	if !r.replayMode {
+		// Plugin actions must be registered before stored show logic runs.
+		if err := r.plugins.Start(r.Device.DeviceID); err != nil {
+			log.Printf("plugins not started: %v", err)
+		}
+		r.plugins.WaitReady()
+	}
 	if profile, err := r.store.LoadProfile(); err == nil {
 		r.Profile = profile
+		declared, err := sensors.Build(profile.Sensors)
+		if err != nil {
+			log.Printf("profile sensors rejected: %v", err)
+		}
+		r.applyProfile(profile, declared)
 	}
 	if def, err := r.store.LoadShowLogic(); err == nil {
 		r.ShowLogic = def
@@ -142,11 +285,65 @@ func (r *Runtime) Boot() error {
 			r.engine.Start(r.lastState.State)
 		}
 	}
-	r.sensors.Start()
+	// The recorder is in place before events are forwarded, since the
+	// forwarder reads r.journal without a lock.
+	if r.recordJournal {
+		recorder, err := journal.NewRecorder(filepath.Join(r.store.DataDir, "journal"), r.Device.DeviceID)
+		if err != nil {
+			log.Printf("journal disabled: %v", err)
+		} else {
+			r.journal = recorder
+			log.Printf("journal recording to %s", recorder.Path())
+		}
+	}
 	go r.forwardSensorEvents()
+	if r.replayMode {
+		return nil
+	}
+	r.sensors.Start()
 	return nil
 }
 
+func (r *Runtime) Shutdown() {
+	r.plugins.Stop()
+	r.sensors.Stop()
+	r.player.Close()
+	if r.journal != nil {
+		r.journal.Close()
+	}
+}
+
+// Replay runs the stored show logic from its initial state and feeds it
+// the sensor events and state updates recorded in a journal. Live sensors
+// and plugins are not started in replay mode.
+func (r *Runtime) Replay(ctx context.Context, path string, speed float64) error {
+	if r.ShowLogic.LogicID == "" {
+		return errors.New("replay: no show logic loaded")
+	}
+	r.engine.Stop()
+	if err := r.engine.Load(r.ShowLogic); err != nil {
+		return err
+	}
+	r.setLastState(types.GlobalStateUpdate{})
+	if speed > 0 {
+		r.engine.SetTimerScale(speed)
+	} else {
+		r.engine.SetTimerScale(math.Inf(1))
+	}
+	r.engine.Start("")
+	return journal.Replay(ctx, path, speed, r)
+}
+
+func (r *Runtime) ReplaySensorEvent(event types.SensorEvent) {
+	r.engine.OnSensorEvent(event)
+}
+
+func (r *Runtime) ReplayState(update types.GlobalStateUpdate) {
+	// The journal already replays updates when they took effect.
+	update.EffectiveAt = nil
+	r.handleStateUpdate(update)
+}
+
 func (r *Runtime) ServerHello(agentVersion string) server.HelloMessage {
 	return server.HelloMessage{
 		Type:             "hello",
@@ -159,6 +356,7 @@ func (r *Runtime) ServerHello(agentVersion string) server.HelloMessage {
 		ProfileVersion:   r.Assignment.ProfileVersion,
 		ShowLogicVersion: r.Assignment.ShowLogicVersion,
 		Capabilities:     r.Capabilities,
+		Sensors:          r.sensors.HealthSnapshot(),
 	}
 }
 
@@ -174,7 +372,24 @@ func (r *Runtime) IncomingChannel() <-chan server.Incoming {
 	return r.serverIncoming
 }
 
+// localState is a state chosen on this device, in offline mode or by the
+// OSC conductor. It goes through the incoming queue like a state_update so
+// that only HandleServerMessage touches lastState.
+type localState struct {
+	state string
+}
+
+func (r *Runtime) postLocalState(state string) bool {
+	select {
+	case r.serverIncoming <- server.Incoming{RawType: "state_update", Payload: localState{state: state}}:
+		return true
+	default:
+		return false
+	}
+}
+
 func (r *Runtime) StartOffline() {
+	r.offline = true
 	if r.ShowLogic.LogicID == "" || len(r.ShowLogic.States) == 0 {
 		log.Printf("offline mode: no show logic loaded")
 		return
@@ -184,16 +399,65 @@ func (r *Runtime) StartOffline() {
 		return
 	}
 	if r.lastState.State == "" {
-		r.lastState = types.GlobalStateUpdate{
+		r.setLastState(types.GlobalStateUpdate{
 			State:     r.ShowLogic.States[0].Name,
 			Version:   1,
 			Timestamp: time.Now().UTC(),
-		}
+		})
+	}
+	if r.journal != nil {
+		r.journal.RecordState(r.lastState)
 	}
 	r.engine.Start(r.lastState.State)
 	log.Printf("offline mode: started at state %s", r.lastState.State)
 }
 
+// ConductorSensorID is the sensor ID that reports loss and recovery of the
+// conductor's OSC heartbeat.
+const ConductorSensorID = "conductor"
+
+// StartOSC follows the conductor's OSC broadcasts instead of a State
+// Server. Each /scene/{id} or /fork/{id} becomes a state update for the
+// state named id, numbered locally.
+func (r *Runtime) StartOSC(ctx context.Context, addr string, heartbeatTimeout time.Duration) {
+	if r.ShowLogic.LogicID == "" || len(r.ShowLogic.States) == 0 {
+		log.Printf("osc mode: no show logic loaded")
+	} else if err := r.engine.Load(r.ShowLogic); err != nil {
+		log.Printf("osc mode: show logic load failed: %v", err)
+	} else {
+		r.engine.Start("")
+	}
+	source := &osc.StateSource{
+		Addr:             addr,
+		HeartbeatTimeout: heartbeatTimeout,
+		OnState: func(kind, id string) {
+			if !r.postLocalState(id) {
+				log.Printf("osc mode: incoming queue full, dropped %s %s", kind, id)
+			}
+		},
+		OnHeartbeat: func(alive bool) {
+			eventType := "heartbeat_lost"
+			if alive {
+				eventType = "heartbeat_restored"
+			}
+			r.sensors.Inject(types.SensorEvent{
+				SensorID:   ConductorSensorID,
+				SensorType: "osc",
+				EventType:  eventType,
+				Value:      r.currentState().State,
+				Timestamp:  time.Now().UTC(),
+			})
+		},
+	}
+	r.oscSource = source
+	go func() {
+		if err := source.Run(ctx); err != nil {
+			log.Printf("osc mode: listener stopped: %v", err)
+		}
+	}()
+	log.Printf("osc mode: following conductor on udp %s", addr)
+}
+
 func (r *Runtime) ApplyDiagnosticShowLogic() error {
 	if _, err := os.Stat(r.store.ShowLogicPath()); err == nil {
 		log.Printf("diagnostic show logic: existing file found, skipping regeneration")
@@ -240,11 +504,35 @@ func (r *Runtime) HandleServerMessage(msg server.Incoming) {
 		}
 	case server.StateUpdateMessage:
 		update := types.GlobalStateUpdate{
-			State:     payload.State,
-			Version:   payload.Version,
-			Timestamp: payload.Timestamp,
+			State:       payload.State,
+			Version:     payload.Version,
+			Timestamp:   payload.Timestamp,
+			EffectiveAt: payload.EffectiveAt,
+		}
+		if r.deferStateUpdate(update) {
+			return
 		}
 		r.handleStateUpdate(update)
+	case types.GlobalStateUpdate:
+		// A state_update deferred until shortly before its effective_at.
+		r.handleStateUpdate(payload)
+	case localState:
+		if payload.state == r.lastState.State {
+			return
+		}
+		r.handleStateUpdate(types.GlobalStateUpdate{
+			State:     payload.state,
+			Version:   r.lastState.Version + 1,
+			Timestamp: time.Now().UTC(),
+		})
+	case server.ClockPongMessage:
+		if !r.clock.Pong(payload.PingID, payload.ServerTime) {
+			log.Printf("clock_pong %d does not match a pending ping", payload.PingID)
+		}
+	case server.RequestStateAck:
+		r.handleStateRequestAck(payload)
+	case server.VoteUpdateMessage:
+		r.handleVoteUpdate(payload)
 	}
 }
 
@@ -252,13 +540,21 @@ func (r *Runtime) handleAssign(msg server.AssignRoleMessage) error {
 	if err := validateProfile(msg.Profile, r.Capabilities); err != nil {
 		return err
 	}
+	if err := r.commands.Validate(msg.Profile.Commands); err != nil {
+		return err
+	}
+	declared, err := sensors.Build(msg.Profile.Sensors)
+	if err != nil {
+		return err
+	}
 	if err := r.store.SaveProfile(msg.Profile); err != nil {
 		return err
 	}
 	if err := r.store.SaveShowLogic(msg.ShowLogic); err != nil {
 		return err
 	}
-	if err := validateShowLogic(msg.ShowLogic, r.supportedActions); err != nil {
+	r.plugins.WaitReady()
+	if err := validateShowLogic(msg.ShowLogic, r.dispatcher.SupportedActions()); err != nil {
 		return err
 	}
 	requiredAssets := requiredAssetsFromLogic(msg.ShowLogic)
@@ -283,6 +579,7 @@ func (r *Runtime) handleAssign(msg server.AssignRoleMessage) error {
 	r.Profile = msg.Profile
 	r.ShowLogic = msg.ShowLogic
 	r.PairingCode = ""
+	r.applyProfile(msg.Profile, declared)
 
 	r.engine.Stop()
 	if err := r.engine.Load(msg.ShowLogic); err != nil {
@@ -292,11 +589,270 @@ func (r *Runtime) handleAssign(msg server.AssignRoleMessage) error {
 	return nil
 }
 
+func (r *Runtime) applyProfile(profile types.ExecutionProfile, declared []sensors.Sensor) {
+	tokens := make(map[string]string, len(profile.SensorTokens))
+	for sensorID, token := range profile.SensorTokens {
+		tokens[sensorID] = token
+	}
+	r.tokensMu.Lock()
+	r.sensorTokens = tokens
+	r.tokensMu.Unlock()
+	heartbeats := make(map[string]time.Duration)
+	for _, cfg := range profile.Sensors {
+		if cfg.HeartbeatMs > 0 {
+			heartbeats[cfg.SensorID] = time.Duration(cfg.HeartbeatMs) * time.Millisecond
+		}
+	}
+	r.sensors.SetHeartbeats(heartbeats)
+	r.sensors.SetGroup("profile", declared)
+	r.mqtt.Configure(profile.MQTT, "deployable-"+r.Device.DeviceID)
+	r.sensors.SetGroup("mqtt", r.mqtt.Sensors())
+	if err := r.commands.Validate(profile.Commands); err != nil {
+		log.Printf("profile commands rejected: %v", err)
+		r.commands.Configure(nil)
+	} else {
+		r.commands.Configure(profile.Commands)
+	}
+}
+
+// RequestState asks the State Server to move the show to state. The answer
+// arrives as an accepted, rejected or timeout event on eventSensorID, when
+// one is given. Without a server the device acts as its own conductor and
+// applies the state directly.
+func (r *Runtime) RequestState(state, reason, eventSensorID string) (string, error) {
+	if r.replayMode {
+		return "", errors.New("state.request ignored during replay")
+	}
+	if r.oscSource != nil {
+		return "", errors.New("state.request needs a State Server; the OSC state source is receive-only")
+	}
+	requestID := newRequestID()
+	if r.offline {
+		if !r.postLocalState(state) {
+			r.emitStateRequestEvent(eventSensorID, "rejected", requestID, state, "incoming queue full")
+			return "", errors.New("state.request: incoming queue full")
+		}
+		r.emitStateRequestEvent(eventSensorID, "accepted", requestID, state, "offline")
+		return requestID, nil
+	}
+	msg := server.RequestStateMessage{
+		Type:      "request_state",
+		DeviceID:  r.Device.DeviceID,
+		RequestID: requestID,
+		State:     state,
+		Reason:    reason,
+		FromState: r.currentState().State,
+		Timestamp: time.Now().UTC(),
+	}
+	r.requestsMu.Lock()
+	pending := &pendingStateRequest{state: state, sensorID: eventSensorID}
+	pending.timer = time.AfterFunc(stateRequestTimeout, func() {
+		r.expireStateRequest(requestID)
+	})
+	r.stateRequests[requestID] = pending
+	r.requestsMu.Unlock()
+	select {
+	case r.serverOutgoing <- msg:
+	default:
+		r.takeStateRequest(requestID)
+		r.emitStateRequestEvent(eventSensorID, "rejected", requestID, state, "server outbox full")
+		return "", errors.New("state.request: server outbox full")
+	}
+	log.Printf("requested state %s (%s)", state, requestID)
+	return requestID, nil
+}
+
+func (r *Runtime) handleStateRequestAck(ack server.RequestStateAck) {
+	pending := r.takeStateRequest(ack.RequestID)
+	if pending == nil {
+		log.Printf("request_state_ack for unknown request %s", ack.RequestID)
+		return
+	}
+	status := ack.Status
+	if status != "accepted" {
+		status = "rejected"
+		log.Printf("state request %s for %s rejected: %s", ack.RequestID, pending.state, ack.Reason)
+	}
+	r.emitStateRequestEvent(pending.sensorID, status, ack.RequestID, pending.state, ack.Reason)
+}
+
+func (r *Runtime) expireStateRequest(requestID string) {
+	pending := r.takeStateRequest(requestID)
+	if pending == nil {
+		return
+	}
+	log.Printf("state request %s for %s timed out", requestID, pending.state)
+	r.emitStateRequestEvent(pending.sensorID, "timeout", requestID, pending.state, "")
+}
+
+func (r *Runtime) takeStateRequest(requestID string) *pendingStateRequest {
+	r.requestsMu.Lock()
+	defer r.requestsMu.Unlock()
+	pending, ok := r.stateRequests[requestID]
+	if !ok {
+		return nil
+	}
+	pending.timer.Stop()
+	delete(r.stateRequests, requestID)
+	return pending
+}
+
+func (r *Runtime) emitStateRequestEvent(sensorID, eventType, requestID, state, reason string) {
+	if sensorID == "" {
+		return
+	}
+	value := map[string]any{
+		"request_id": requestID,
+		"state":      state,
+	}
+	if reason != "" {
+		value["reason"] = reason
+	}
+	r.sensors.Inject(types.SensorEvent{
+		SensorID:   sensorID,
+		SensorType: "state_request",
+		EventType:  eventType,
+		Value:      value,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// PlaybackSensorID is the sensor ID under which playback events, such as
+// a media player crashing and restarting, reach the engine.
+const PlaybackSensorID = "playback"
+
+func (r *Runtime) handlePlaybackEvent(event playback.Event) {
+	value := map[string]any{
+		"output_id": event.OutputID,
+	}
+	if event.Asset != "" {
+		value["asset"] = event.Asset
+	}
+	if event.Error != "" {
+		value["error"] = event.Error
+	}
+	if event.Restarts > 0 {
+		value["restarts"] = event.Restarts
+	}
+	r.sensors.Inject(types.SensorEvent{
+		SensorID:   PlaybackSensorID,
+		SensorType: "playback",
+		EventType:  event.Type,
+		Value:      value,
+		Timestamp:  event.Timestamp,
+	})
+}
+
+// handleVoteUpdate turns live tallies into engine events: vote_update on
+// every message, vote_leader when the leading choice changes (-1 on a tie),
+// and vote_closed with the winning index once the countdown ends.
+func (r *Runtime) handleVoteUpdate(msg server.VoteUpdateMessage) {
+	total := 0
+	leader := -1
+	best := -1
+	for i, count := range msg.Counts {
+		total += count
+		if count > best {
+			best, leader = count, i
+		} else if count == best {
+			leader = -1
+		}
+	}
+	if total == 0 {
+		leader = -1
+	}
+	shares := make([]float64, len(msg.Counts))
+	for i, count := range msg.Counts {
+		if total > 0 {
+			shares[i] = float64(count) / float64(total)
+		}
+	}
+	value := map[string]any{
+		"fork_id":           msg.ForkID,
+		"choices":           msg.Choices,
+		"counts":            msg.Counts,
+		"shares":            shares,
+		"total":             total,
+		"leader":            leader,
+		"countdown_seconds": msg.CountdownSeconds,
+		"remaining_ms":      msg.RemainingMs,
+		"closed":            msg.Closed,
+	}
+	if leader >= 0 && leader < len(msg.Choices) {
+		value["leader_label"] = msg.Choices[leader].Label
+		value["leader_next_state_id"] = msg.Choices[leader].NextStateID
+	}
+	r.emitVoteEvent("vote_update", value)
+	if msg.ForkID == r.closedVote {
+		if msg.Closed {
+			return
+		}
+		r.closedVote = ""
+	}
+	progress, ok := r.votes[msg.ForkID]
+	if !ok {
+		progress = &voteProgress{leader: -1}
+		r.votes[msg.ForkID] = progress
+	}
+	if leader != progress.leader {
+		progress.leader = leader
+		r.emitVoteEvent("vote_leader", float64(leader))
+	}
+	if msg.Closed {
+		delete(r.votes, msg.ForkID)
+		r.closedVote = msg.ForkID
+		r.emitVoteEvent("vote_closed", float64(leader))
+	}
+}
+
+func (r *Runtime) emitVoteEvent(eventType string, value any) {
+	r.sensors.Inject(types.SensorEvent{
+		SensorID:   VoteSensorID,
+		SensorType: "vote",
+		EventType:  eventType,
+		Value:      value,
+		Timestamp:  time.Now().UTC(),
+	})
+}
+
+// deferStateUpdate holds back an update whose effective_at is more than
+// the sync lead away, redelivering it through the incoming queue once it
+// is due. Updates are handled in order of delivery, so a newer update
+// that arrives in the meantime supersedes it.
+func (r *Runtime) deferStateUpdate(update types.GlobalStateUpdate) bool {
+	if update.EffectiveAt == nil {
+		return false
+	}
+	wait := time.Until(r.clock.ToLocal(*update.EffectiveAt)) - r.syncLead
+	if wait <= 0 {
+		return false
+	}
+	time.AfterFunc(wait, func() {
+		r.serverIncoming <- server.Incoming{RawType: "state_update", Payload: update}
+	})
+	return true
+}
+
+func (r *Runtime) setLastState(update types.GlobalStateUpdate) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.lastState = update
+}
+
+func (r *Runtime) currentState() types.GlobalStateUpdate {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	return r.lastState
+}
+
 func (r *Runtime) handleStateUpdate(update types.GlobalStateUpdate) {
 	if update.Version <= r.lastState.Version {
 		return
 	}
-	r.lastState = update
+	r.setLastState(update)
+	if r.journal != nil {
+		r.journal.RecordState(update)
+	}
 	r.engine.OnGlobalState(update)
 }
 
@@ -308,7 +864,17 @@ func (r *Runtime) forwardActions() {
 
 func (r *Runtime) forwardSensorEvents() {
 	for event := range r.sensors.EventChannel() {
+		if event.DeviceID == "" {
+			event.DeviceID = r.Device.DeviceID
+		}
+		if r.journal != nil {
+			r.journal.RecordSensor(event)
+		}
 		r.engine.OnSensorEvent(event)
+		if event.SensorType == "vote" {
+			// Vote events come from the server; echoing them back is noise.
+			continue
+		}
 		r.serverOutgoing <- server.SensorEventMessage{
 			Type:        "sensor_event",
 			SensorEvent: event,
@@ -316,6 +882,92 @@ func (r *Runtime) forwardSensorEvents() {
 	}
 }
 
+// reportSensorHealth sends the full health list on every transition so the
+// server never has to merge partial updates. It drops the report rather
+// than stall the sensor relay when the outbox is backed up.
+func (r *Runtime) reportSensorHealth(changed types.SensorHealth) {
+	select {
+	case r.serverOutgoing <- server.SensorHealthMessage{
+		Type:      "sensor_health",
+		DeviceID:  r.Device.DeviceID,
+		Sensors:   r.sensors.HealthSnapshot(),
+		Timestamp: time.Now().UTC(),
+	}:
+	default:
+		log.Printf("sensor %s is %s; health report dropped, server outbox full", changed.SensorID, changed.Status)
+	}
+}
+
+// ReportPlaybackStatus sends the status of every output to the server
+// each interval until ctx is done. A report is skipped rather than queued
+// behind a backed up outbox, since the next one supersedes it.
+func (r *Runtime) ReportPlaybackStatus(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if len(r.serverOutgoing) > cap(r.serverOutgoing)/2 {
+			continue
+		}
+		select {
+		case r.serverOutgoing <- server.PlaybackStatusMessage{
+			Type:      "playback_status",
+			DeviceID:  r.Device.DeviceID,
+			Outputs:   r.player.Status(),
+			Timestamp: time.Now().UTC(),
+		}:
+		default:
+		}
+	}
+}
+
+// SyncClock measures the offset to the State Server's clock: every
+// interval it sends a short burst of clock_ping messages, and the
+// clock_pong replies refine the estimate used to convert effective_at
+// times. Pings are skipped while the outbox is backed up, since queueing
+// would inflate their round trip.
+func (r *Runtime) SyncClock(ctx context.Context, interval time.Duration) {
+	const (
+		burst        = 5
+		burstSpacing = 200 * time.Millisecond
+	)
+	next := time.After(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-next:
+		}
+		next = time.After(interval)
+		for i := 0; i < burst; i++ {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(burstSpacing):
+				}
+			}
+			if len(r.serverOutgoing) > cap(r.serverOutgoing)/2 {
+				continue
+			}
+			id, sentAt := r.clock.Ping()
+			select {
+			case r.serverOutgoing <- server.ClockPingMessage{
+				Type:       "clock_ping",
+				DeviceID:   r.Device.DeviceID,
+				PingID:     id,
+				ClientTime: sentAt.UTC(),
+			}:
+			default:
+			}
+		}
+	}
+}
+
 func (r *Runtime) forwardActionErrors() {
 	for failure := range r.actionErrors {
 		r.serverOutgoing <- server.PlaybackErrorMessage{
@@ -328,6 +980,88 @@ func (r *Runtime) forwardActionErrors() {
 	}
 }
 
+func (r *Runtime) AuthorizeSensor(sensorID, token string) bool {
+	r.tokensMu.RLock()
+	tokens := r.sensorTokens
+	r.tokensMu.RUnlock()
+	expected, ok := tokens[sensorID]
+	if sensor, found := r.sensors.Lookup(sensorID); found {
+		if httpSensor, isHTTP := sensor.(*sensors.HTTPSensor); isHTTP {
+			expected, ok = httpSensor.Token(), true
+		}
+	}
+	if !ok {
+		expected, ok = tokens["*"]
+	}
+	if !ok || expected == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
+func (r *Runtime) InjectSensorEvent(event types.SensorEvent) {
+	r.sensors.Inject(event)
+}
+
+type PanelSensor struct {
+	SensorID   string   `json:"sensor_id"`
+	SensorType string   `json:"sensor_type,omitempty"`
+	States     []string `json:"states"`
+	EventTypes []string `json:"event_types"`
+	Values     []any    `json:"values"`
+}
+
+// PanelSensors lists every sensor referenced by a handler in the loaded show
+// logic, with the event types and condition values the handlers look for.
+func (r *Runtime) PanelSensors() []PanelSensor {
+	out := []PanelSensor{}
+	index := map[string]int{}
+	for _, state := range r.ShowLogic.States {
+		for _, handler := range state.SensorHandlers {
+			if handler.SensorID == "" {
+				continue
+			}
+			i, ok := index[handler.SensorID]
+			if !ok {
+				i = len(out)
+				index[handler.SensorID] = i
+				entry := PanelSensor{SensorID: handler.SensorID, States: []string{}, EventTypes: []string{}, Values: []any{}}
+				if sensor, found := r.sensors.Lookup(handler.SensorID); found {
+					entry.SensorType = sensor.Type()
+				}
+				out = append(out, entry)
+			}
+			entry := &out[i]
+			entry.States = appendUnique(entry.States, state.Name)
+			if handler.EventType != "" {
+				entry.EventTypes = appendUnique(entry.EventTypes, handler.EventType)
+			}
+			if eq, ok := handler.Condition["eq"]; ok && !containsValue(entry.Values, eq) {
+				entry.Values = append(entry.Values, eq)
+			}
+		}
+	}
+	return out
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func containsValue(list []any, value any) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Runtime) TriggerIdentify() bool {
 	supported := len(r.Capabilities.VideoOutputs) > 0 || len(r.Capabilities.AudioOutputs) > 0 || len(r.Capabilities.StatusLEDs) > 0
 	if supported {
@@ -337,20 +1071,83 @@ func (r *Runtime) TriggerIdentify() bool {
 }
 
 func (r *Runtime) Status() map[string]any {
-	return map[string]any{
+	status := map[string]any{
 		"device":       r.Device,
 		"assignment":   r.Assignment,
-		"profile":      r.Profile,
+		"profile":      publicProfile(r.Profile),
 		"show_logic":   r.ShowLogic,
 		"capabilities": r.Capabilities,
 		"pairing_code": r.PairingCode,
-		"last_state":   r.lastState,
+		"last_state":   r.currentState(),
 		"last_connected": r.lastConnected,
+		"clock":          r.clock.Snapshot(),
 		"outputs": map[string]any{
 			"playback": r.player.Snapshot(),
 		},
 		"sensors": r.sensors.Snapshot(),
+		"mqtt":    r.mqtt.Snapshot(),
+		"plugins": r.plugins.Snapshot(),
 	}
+	if r.oscSource != nil {
+		status["osc"] = r.oscSource.Snapshot()
+	}
+	return status
+}
+
+// redacted replaces secrets in Status, which is served without auth.
+const redacted = "redacted"
+
+// publicProfile copies profile with its secrets redacted. Keys are kept so
+// the status still shows which sensors have tokens.
+func publicProfile(profile types.ExecutionProfile) types.ExecutionProfile {
+	if len(profile.SensorTokens) > 0 {
+		tokens := make(map[string]string, len(profile.SensorTokens))
+		for sensorID := range profile.SensorTokens {
+			tokens[sensorID] = redacted
+		}
+		profile.SensorTokens = tokens
+	}
+	if len(profile.Sensors) > 0 {
+		declared := make([]types.SensorConfig, len(profile.Sensors))
+		for i, sensor := range profile.Sensors {
+			if _, ok := sensor.Config["token"]; ok {
+				config := make(map[string]any, len(sensor.Config))
+				for key, value := range sensor.Config {
+					config[key] = value
+				}
+				config["token"] = redacted
+				sensor.Config = config
+			}
+			declared[i] = sensor
+		}
+		profile.Sensors = declared
+	}
+	if len(profile.Commands) > 0 {
+		commands := make([]types.CommandDeclaration, len(profile.Commands))
+		for i, command := range profile.Commands {
+			if len(command.Env) > 0 {
+				env := make(map[string]string, len(command.Env))
+				for key := range command.Env {
+					env[key] = redacted
+				}
+				command.Env = env
+			}
+			commands[i] = command
+		}
+		profile.Commands = commands
+	}
+	if len(profile.MQTT) > 0 {
+		brokers := make([]types.MQTTBroker, len(profile.MQTT))
+		for i, broker := range profile.MQTT {
+			if broker.Password != "" {
+				broker.Password = redacted
+			}
+			broker.URL = mqtt.RedactURL(broker.URL)
+			brokers[i] = broker
+		}
+		profile.MQTT = brokers
+	}
+	return profile
 }
 
 func buildOutputDevices(caps types.CapabilityReport) []playback.OutputDevice {
@@ -477,6 +1274,20 @@ func validateProfile(profile types.ExecutionProfile, caps types.CapabilityReport
 			return errors.New("profile requires video outputs")
 		}
 	}
+	if err := mqtt.ValidateBrokers(profile.MQTT); err != nil {
+		return err
+	}
+	sensorIDs := map[string]bool{}
+	for _, sensor := range profile.Sensors {
+		sensorIDs[sensor.SensorID] = true
+	}
+	for _, broker := range profile.MQTT {
+		for _, sub := range broker.Subscriptions {
+			if sensorIDs[sub.SensorID] {
+				return errors.New("sensor id declared twice: " + sub.SensorID)
+			}
+		}
+	}
 	return nil
 }
 
@@ -521,9 +1332,7 @@ func requiredAssetsFromLogic(def types.ShowLogicDefinition) []string {
 	assets := map[string]bool{}
 	for _, state := range def.States {
 		for _, action := range gatherActions(state) {
-			if file, ok := action.Params["file"].(string); ok && file != "" {
-				assets[filepath.Clean(file)] = true
-			}
+			addParamFiles(assets, action.Params)
 		}
 	}
 	list := make([]string, 0, len(assets))
@@ -533,6 +1342,21 @@ func requiredAssetsFromLogic(def types.ShowLogicDefinition) []string {
 	return list
 }
 
+// addParamFiles adds params.file, and the files of a play_sync action's
+// params.items, to assets.
+func addParamFiles(assets map[string]bool, params map[string]any) {
+	if file, ok := params["file"].(string); ok && file != "" {
+		assets[filepath.Clean(file)] = true
+	}
+	items, _ := params["items"].([]any)
+	for _, raw := range items {
+		item, _ := raw.(map[string]any)
+		if itemParams, ok := item["params"].(map[string]any); ok {
+			addParamFiles(assets, itemParams)
+		}
+	}
+}
+
 func gatherActions(state types.ShowState) []types.ActionTemplate {
 	var actions []types.ActionTemplate
 	actions = append(actions, state.OnEnter...)
@@ -546,6 +1370,12 @@ func gatherActions(state types.ShowState) []types.ActionTemplate {
 	return actions
 }
 
+func newRequestID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
 func generatePairingCode() string {
 	var buf [4]byte
 	_, _ = rand.Read(buf[:])
@@ -0,0 +1,134 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+)
+
+func init() {
+	RegisterDriver("http", newHTTPSensor)
+	RegisterDriver("interval", newIntervalSensor)
+}
+
+// HTTPSensor is a passive sensor fed by the web server's sensor endpoint.
+// Declaring it gives the sensor a token and makes it part of the active set.
+type HTTPSensor struct {
+	id    string
+	token string
+}
+
+func newHTTPSensor(cfg types.SensorConfig) (Sensor, error) {
+	token, _ := cfg.Config["token"].(string)
+	if token == "" {
+		return nil, errors.New("http sensor requires config.token")
+	}
+	return &HTTPSensor{id: cfg.SensorID, token: token}, nil
+}
+
+func (s *HTTPSensor) ID() string {
+	return s.id
+}
+
+func (s *HTTPSensor) Type() string {
+	return "http"
+}
+
+func (s *HTTPSensor) Capabilities() map[string]any {
+	return map[string]any{
+		"endpoint": "/api/sensors/" + s.id,
+	}
+}
+
+func (s *HTTPSensor) Token() string {
+	return s.token
+}
+
+func (s *HTTPSensor) Start(eventSink chan<- types.SensorEvent) {}
+
+func (s *HTTPSensor) Stop() {}
+
+// IntervalSensor emits a tick event at a fixed period, useful for periodic
+// show logic and for checking that handlers fire during rehearsal.
+type IntervalSensor struct {
+	id        string
+	interval  time.Duration
+	eventType string
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+func newIntervalSensor(cfg types.SensorConfig) (Sensor, error) {
+	ms, ok := cfg.Config["interval_ms"].(float64)
+	if !ok || ms < 10 {
+		return nil, fmt.Errorf("interval sensor requires config.interval_ms >= 10")
+	}
+	eventType, _ := cfg.Config["event_type"].(string)
+	if eventType == "" {
+		eventType = "tick"
+	}
+	return &IntervalSensor{
+		id:        cfg.SensorID,
+		interval:  time.Duration(ms) * time.Millisecond,
+		eventType: eventType,
+	}, nil
+}
+
+func (s *IntervalSensor) ID() string {
+	return s.id
+}
+
+func (s *IntervalSensor) Type() string {
+	return "interval"
+}
+
+func (s *IntervalSensor) Capabilities() map[string]any {
+	return map[string]any{
+		"interval_ms": s.interval.Milliseconds(),
+		"event_type":  s.eventType,
+	}
+}
+
+func (s *IntervalSensor) Start(eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		count := 0
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				count++
+				eventSink <- types.SensorEvent{
+					SensorID:   s.id,
+					SensorType: "interval",
+					EventType:  s.eventType,
+					Value:      float64(count),
+					Timestamp:  now.UTC(),
+				}
+			}
+		}
+	}()
+}
+
+func (s *IntervalSensor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
@@ -0,0 +1,422 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+)
+
+func init() {
+	RegisterDriver("derived", newDerivedSensor)
+}
+
+// Deriver is implemented by virtual sensors computed from another sensor's
+// events. The manager feeds every event from Source through Derive.
+type Deriver interface {
+	Source() string
+	Derive(event types.SensorEvent) (types.SensorEvent, bool)
+}
+
+// DerivedSensor conditions the numeric readings of a source sensor through
+// a declared chain of transforms and emits the result under its own ID.
+type DerivedSensor struct {
+	id         string
+	source     string
+	eventTypes map[string]bool
+	eventType  string
+	specs      []map[string]any
+
+	mu         sync.Mutex
+	running    bool
+	transforms []transform
+	report     HealthReporter
+}
+
+type transform interface {
+	// apply returns the conditioned value, or false to drop the reading.
+	apply(value float64, at time.Time) (float64, bool)
+}
+
+func newDerivedSensor(cfg types.SensorConfig) (Sensor, error) {
+	source, _ := cfg.Config["source"].(string)
+	if source == "" {
+		return nil, errors.New("derived sensor requires config.source")
+	}
+	if source == cfg.SensorID {
+		return nil, errors.New("derived sensor cannot use itself as source")
+	}
+	filter, err := stringList(cfg.Config["source_event_types"])
+	if err != nil {
+		return nil, fmt.Errorf("derived source_event_types: %w", err)
+	}
+	var eventTypes map[string]bool
+	if len(filter) > 0 {
+		eventTypes = map[string]bool{}
+		for _, eventType := range filter {
+			eventTypes[eventType] = true
+		}
+	}
+	eventType, _ := cfg.Config["event_type"].(string)
+	if eventType == "" {
+		eventType = "value"
+	}
+	rawList, _ := cfg.Config["transforms"].([]any)
+	if cfg.Config["transforms"] != nil && rawList == nil {
+		return nil, errors.New("derived transforms must be a list")
+	}
+	specs := make([]map[string]any, 0, len(rawList))
+	for i, raw := range rawList {
+		spec, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("derived transform %d must be an object", i)
+		}
+		specs = append(specs, spec)
+	}
+	if _, err := buildTransforms(specs); err != nil {
+		return nil, err
+	}
+	return &DerivedSensor{
+		id:         cfg.SensorID,
+		source:     source,
+		eventTypes: eventTypes,
+		eventType:  eventType,
+		specs:      specs,
+	}, nil
+}
+
+func (s *DerivedSensor) ID() string {
+	return s.id
+}
+
+func (s *DerivedSensor) Type() string {
+	return "derived"
+}
+
+func (s *DerivedSensor) Capabilities() map[string]any {
+	return map[string]any{
+		"source":     s.source,
+		"event_type": s.eventType,
+		"transforms": s.specs,
+	}
+}
+
+func (s *DerivedSensor) Source() string {
+	return s.source
+}
+
+// Start resets the transform state; readings flow in through Derive.
+func (s *DerivedSensor) Start(eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.transforms, _ = buildTransforms(s.specs)
+	s.running = true
+}
+
+func (s *DerivedSensor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+func (s *DerivedSensor) SetHealthReporter(report HealthReporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report = report
+}
+
+func (s *DerivedSensor) Derive(event types.SensorEvent) (types.SensorEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.running {
+		return types.SensorEvent{}, false
+	}
+	if s.eventTypes != nil && !s.eventTypes[event.EventType] {
+		return types.SensorEvent{}, false
+	}
+	value, ok := numericValue(event.Value)
+	if !ok {
+		if s.report != nil {
+			s.report(fmt.Errorf("source %s sent non-numeric value %v", s.source, event.Value), false)
+		}
+		return types.SensorEvent{}, false
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	var boolean bool
+	for _, t := range s.transforms {
+		if value, ok = t.apply(value, at); !ok {
+			return types.SensorEvent{}, false
+		}
+		_, boolean = t.(*thresholdTransform)
+	}
+	var out any = value
+	if boolean {
+		out = value != 0
+	}
+	return types.SensorEvent{
+		DeviceID:   event.DeviceID,
+		SensorID:   s.id,
+		SensorType: "derived",
+		EventType:  s.eventType,
+		Value:      out,
+		Timestamp:  at.UTC(),
+	}, true
+}
+
+func buildTransforms(specs []map[string]any) ([]transform, error) {
+	out := make([]transform, 0, len(specs))
+	for i, spec := range specs {
+		kind, _ := spec["type"].(string)
+		t, err := newTransform(kind, spec)
+		if err != nil {
+			return nil, fmt.Errorf("derived transform %d (%s): %w", i, kind, err)
+		}
+		if i < len(specs)-1 {
+			if _, ok := t.(*thresholdTransform); ok {
+				return nil, fmt.Errorf("derived transform %d (threshold) must be last", i)
+			}
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func newTransform(kind string, spec map[string]any) (transform, error) {
+	switch kind {
+	case "scale":
+		factor, err := numberParam(spec, "factor", 1)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := numberParam(spec, "offset", 0)
+		if err != nil {
+			return nil, err
+		}
+		return &scaleTransform{factor: factor, offset: offset}, nil
+	case "clamp":
+		min, err := numberParam(spec, "min", math.Inf(-1))
+		if err != nil {
+			return nil, err
+		}
+		max, err := numberParam(spec, "max", math.Inf(1))
+		if err != nil {
+			return nil, err
+		}
+		if min > max {
+			return nil, errors.New("min must not exceed max")
+		}
+		return &clampTransform{min: min, max: max}, nil
+	case "moving_average":
+		window, err := numberParam(spec, "window", 0)
+		if err != nil {
+			return nil, err
+		}
+		if window < 1 || window != math.Trunc(window) {
+			return nil, errors.New("window must be a positive integer")
+		}
+		return &movingAverageTransform{window: int(window)}, nil
+	case "ema":
+		alpha, err := numberParam(spec, "alpha", 0)
+		if err != nil {
+			return nil, err
+		}
+		if alpha <= 0 || alpha > 1 {
+			return nil, errors.New("alpha must be in (0, 1]")
+		}
+		return &emaTransform{alpha: alpha}, nil
+	case "deadband":
+		width, err := numberParam(spec, "width", 0)
+		if err != nil {
+			return nil, err
+		}
+		if width < 0 {
+			return nil, errors.New("width must not be negative")
+		}
+		return &deadbandTransform{width: width}, nil
+	case "threshold":
+		on, err := numberParam(spec, "on", math.NaN())
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(on) {
+			return nil, errors.New("on is required")
+		}
+		off, err := numberParam(spec, "off", on)
+		if err != nil {
+			return nil, err
+		}
+		if off > on {
+			return nil, errors.New("off must not exceed on")
+		}
+		return &thresholdTransform{on: on, off: off}, nil
+	case "rate":
+		return &rateTransform{}, nil
+	case "":
+		return nil, errors.New("missing type")
+	default:
+		return nil, errors.New("unknown transform type")
+	}
+}
+
+func numberParam(spec map[string]any, key string, fallback float64) (float64, error) {
+	raw, ok := spec[key]
+	if !ok || raw == nil {
+		return fallback, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	return value, nil
+}
+
+// numericValue accepts numbers, booleans and numeric strings, which covers
+// what HTTP, MQTT and plugin sensors typically send.
+func numericValue(raw any) (float64, bool) {
+	switch value := raw.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case bool:
+		if value {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+type scaleTransform struct {
+	factor float64
+	offset float64
+}
+
+func (t *scaleTransform) apply(value float64, at time.Time) (float64, bool) {
+	return value*t.factor + t.offset, true
+}
+
+type clampTransform struct {
+	min float64
+	max float64
+}
+
+func (t *clampTransform) apply(value float64, at time.Time) (float64, bool) {
+	return math.Max(t.min, math.Min(t.max, value)), true
+}
+
+type movingAverageTransform struct {
+	window  int
+	samples []float64
+	sum     float64
+}
+
+func (t *movingAverageTransform) apply(value float64, at time.Time) (float64, bool) {
+	t.samples = append(t.samples, value)
+	t.sum += value
+	if len(t.samples) > t.window {
+		t.sum -= t.samples[0]
+		t.samples = t.samples[1:]
+	}
+	return t.sum / float64(len(t.samples)), true
+}
+
+type emaTransform struct {
+	alpha  float64
+	value  float64
+	primed bool
+}
+
+func (t *emaTransform) apply(value float64, at time.Time) (float64, bool) {
+	if !t.primed {
+		t.value, t.primed = value, true
+		return value, true
+	}
+	t.value += t.alpha * (value - t.value)
+	return t.value, true
+}
+
+// deadbandTransform passes a reading only once it has moved more than width
+// away from the last reading it passed.
+type deadbandTransform struct {
+	width  float64
+	last   float64
+	primed bool
+}
+
+func (t *deadbandTransform) apply(value float64, at time.Time) (float64, bool) {
+	if t.primed && math.Abs(value-t.last) <= t.width {
+		return 0, false
+	}
+	t.last, t.primed = value, true
+	return value, true
+}
+
+// thresholdTransform switches on at or above on and off below off,
+// emitting only when the state changes. With off below on the gap acts as
+// hysteresis.
+type thresholdTransform struct {
+	on     float64
+	off    float64
+	state  bool
+	primed bool
+}
+
+func (t *thresholdTransform) apply(value float64, at time.Time) (float64, bool) {
+	next := t.state
+	if !t.primed {
+		next = value >= t.on
+	} else if t.state && value < t.off {
+		next = false
+	} else if !t.state && value >= t.on {
+		next = true
+	}
+	if t.primed && next == t.state {
+		return 0, false
+	}
+	t.state, t.primed = next, true
+	if next {
+		return 1, true
+	}
+	return 0, true
+}
+
+// rateTransform yields the change per second between consecutive readings.
+type rateTransform struct {
+	last   float64
+	lastAt time.Time
+	primed bool
+}
+
+func (t *rateTransform) apply(value float64, at time.Time) (float64, bool) {
+	if !t.primed {
+		t.last, t.lastAt, t.primed = value, at, true
+		return 0, false
+	}
+	elapsed := at.Sub(t.lastAt).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	rate := (value - t.last) / elapsed
+	t.last, t.lastAt = value, at
+	return rate, true
+}
+
@@ -0,0 +1,190 @@
+package sensors
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"deployable/internal/types"
+)
+
+// dropped marks a reading the derived sensor must not emit.
+var dropped = math.NaN()
+
+type reading struct {
+	value any
+	at    time.Duration
+}
+
+func newTestDerived(t *testing.T, transforms ...map[string]any) *DerivedSensor {
+	t.Helper()
+	list := make([]any, len(transforms))
+	for i, spec := range transforms {
+		list[i] = spec
+	}
+	sensor, err := newDerivedSensor(types.SensorConfig{
+		SensorID: "level",
+		Config:   map[string]any{"source": "raw", "transforms": list},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived := sensor.(*DerivedSensor)
+	derived.Start(nil)
+	return derived
+}
+
+func TestDerivedTransforms(t *testing.T) {
+	base := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name       string
+		transforms []map[string]any
+		readings   []reading
+		want       []float64
+	}{
+		{
+			name:       "scale and offset",
+			transforms: []map[string]any{{"type": "scale", "factor": 2.0, "offset": -1.0}},
+			readings:   []reading{{value: 3.0}, {value: "0.5"}, {value: true}},
+			want:       []float64{5, 0, 1},
+		},
+		{
+			name:       "clamp",
+			transforms: []map[string]any{{"type": "clamp", "min": 0.0, "max": 10.0}},
+			readings:   []reading{{value: -5.0}, {value: 4.0}, {value: 12.0}},
+			want:       []float64{0, 4, 10},
+		},
+		{
+			name:       "moving average fills then slides its window",
+			transforms: []map[string]any{{"type": "moving_average", "window": 3.0}},
+			readings:   []reading{{value: 3.0}, {value: 6.0}, {value: 9.0}, {value: 12.0}, {value: 0.0}},
+			want:       []float64{3, 4.5, 6, 9, 7},
+		},
+		{
+			name:       "ema starts at the first reading",
+			transforms: []map[string]any{{"type": "ema", "alpha": 0.5}},
+			readings:   []reading{{value: 10.0}, {value: 20.0}, {value: 20.0}},
+			want:       []float64{10, 15, 17.5},
+		},
+		{
+			name:       "deadband measures from the last reading passed",
+			transforms: []map[string]any{{"type": "deadband", "width": 1.0}},
+			readings:   []reading{{value: 5.0}, {value: 5.5}, {value: 6.0}, {value: 6.2}, {value: 4.0}},
+			want:       []float64{5, dropped, dropped, 6.2, 4},
+		},
+		{
+			name:       "threshold switches with hysteresis",
+			transforms: []map[string]any{{"type": "threshold", "on": 10.0, "off": 5.0}},
+			readings:   []reading{{value: 2.0}, {value: 8.0}, {value: 10.0}, {value: 7.0}, {value: 5.0}, {value: 4.9}, {value: 9.0}},
+			want:       []float64{0, dropped, 1, dropped, dropped, 0, dropped},
+		},
+		{
+			name:       "threshold without off switches at on",
+			transforms: []map[string]any{{"type": "threshold", "on": 1.0}},
+			readings:   []reading{{value: 1.0}, {value: 0.5}, {value: 0.9}, {value: 1.0}},
+			want:       []float64{1, 0, dropped, 1},
+		},
+		{
+			name:       "rate drops the first reading",
+			transforms: []map[string]any{{"type": "rate"}},
+			readings: []reading{
+				{value: 10.0, at: 0},
+				{value: 14.0, at: 2 * time.Second},
+				{value: 14.0, at: 2 * time.Second},
+				{value: 13.0, at: 2500 * time.Millisecond},
+			},
+			want: []float64{dropped, 2, dropped, -2},
+		},
+		{
+			name: "chain applies in order",
+			transforms: []map[string]any{
+				{"type": "scale", "factor": 100.0},
+				{"type": "moving_average", "window": 2.0},
+				{"type": "threshold", "on": 50.0, "off": 40.0},
+			},
+			readings: []reading{{value: 0.2}, {value: 0.8}, {value: 0.6}, {value: 0.2}, {value: 0.1}},
+			want:     []float64{0, 1, dropped, dropped, 0},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sensor := newTestDerived(t, tc.transforms...)
+			for i, r := range tc.readings {
+				event, ok := sensor.Derive(types.SensorEvent{
+					SensorID:  "raw",
+					EventType: "value",
+					Value:     r.value,
+					Timestamp: base.Add(r.at),
+				})
+				want := tc.want[i]
+				if math.IsNaN(want) {
+					if ok {
+						t.Fatalf("reading %d (%v) emitted %v, want it dropped", i, r.value, event.Value)
+					}
+					continue
+				}
+				if !ok {
+					t.Fatalf("reading %d (%v) dropped, want %v", i, r.value, want)
+				}
+				got, _ := numericValue(event.Value)
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("reading %d (%v) gave %v, want %v", i, r.value, event.Value, want)
+				}
+				if event.SensorID != "level" || event.EventType != "value" {
+					t.Fatalf("reading %d emitted as %s/%s", i, event.SensorID, event.EventType)
+				}
+			}
+		})
+	}
+}
+
+func TestDerivedThresholdEmitsBooleans(t *testing.T) {
+	sensor := newTestDerived(t, map[string]any{"type": "threshold", "on": 1.0})
+	event, ok := sensor.Derive(types.SensorEvent{SensorID: "raw", Value: 2.0})
+	if !ok || event.Value != true {
+		t.Fatalf("threshold emitted %v (%v), want true", event.Value, ok)
+	}
+}
+
+func TestDerivedDropsNonNumericReadings(t *testing.T) {
+	sensor := newTestDerived(t)
+	var reported error
+	sensor.SetHealthReporter(func(err error, offline bool) { reported = err })
+	if _, ok := sensor.Derive(types.SensorEvent{SensorID: "raw", Value: "open"}); ok {
+		t.Fatal("non-numeric reading emitted")
+	}
+	if reported == nil {
+		t.Fatal("non-numeric reading not reported")
+	}
+}
+
+func TestDerivedRejectsInvalidTransforms(t *testing.T) {
+	cases := []struct {
+		name       string
+		transforms []any
+		want       string
+	}{
+		{"threshold must be last", []any{map[string]any{"type": "threshold", "on": 1.0}, map[string]any{"type": "scale"}}, "must be last"},
+		{"window must be a positive integer", []any{map[string]any{"type": "moving_average", "window": 2.5}}, "window"},
+		{"alpha must be in range", []any{map[string]any{"type": "ema", "alpha": 0.0}}, "alpha"},
+		{"deadband width must not be negative", []any{map[string]any{"type": "deadband", "width": -1.0}}, "width"},
+		{"threshold needs on", []any{map[string]any{"type": "threshold"}}, "on is required"},
+		{"threshold off must not exceed on", []any{map[string]any{"type": "threshold", "on": 1.0, "off": 2.0}}, "off must not exceed on"},
+		{"clamp min must not exceed max", []any{map[string]any{"type": "clamp", "min": 2.0, "max": 1.0}}, "min must not exceed max"},
+		{"unknown type", []any{map[string]any{"type": "median"}}, "unknown transform type"},
+		{"numbers must be numbers", []any{map[string]any{"type": "scale", "factor": "2"}}, "factor must be a number"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newDerivedSensor(types.SensorConfig{
+				SensorID: "level",
+				Config:   map[string]any{"source": "raw", "transforms": tc.transforms},
+			})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("got error %v, want one containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
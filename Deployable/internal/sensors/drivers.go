@@ -0,0 +1,85 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"deployable/internal/types"
+)
+
+type Factory func(cfg types.SensorConfig) (Sensor, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Factory{}
+)
+
+func RegisterDriver(name string, factory Factory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[name] = factory
+}
+
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build instantiates the sensors declared in an execution profile without
+// starting them, failing on the first invalid declaration.
+func Build(configs []types.SensorConfig) ([]Sensor, error) {
+	out := make([]Sensor, 0, len(configs))
+	seen := map[string]bool{}
+	for _, cfg := range configs {
+		if cfg.SensorID == "" {
+			return nil, errors.New("sensor missing sensor_id")
+		}
+		if seen[cfg.SensorID] {
+			return nil, errors.New("duplicate sensor " + cfg.SensorID)
+		}
+		seen[cfg.SensorID] = true
+		driversMu.RLock()
+		factory, ok := drivers[cfg.Driver]
+		driversMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("sensor %s uses unknown driver %q", cfg.SensorID, cfg.Driver)
+		}
+		sensor, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("sensor %s: %w", cfg.SensorID, err)
+		}
+		out = append(out, sensor)
+	}
+	if err := checkDerivedCycles(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func checkDerivedCycles(list []Sensor) error {
+	sources := map[string]string{}
+	for _, sensor := range list {
+		if d, ok := sensor.(Deriver); ok {
+			sources[sensor.ID()] = d.Source()
+		}
+	}
+	for id := range sources {
+		seen := map[string]bool{id: true}
+		for next, ok := sources[id]; ok; next, ok = sources[next] {
+			if seen[next] {
+				return fmt.Errorf("derived sensor %s is part of a source cycle", id)
+			}
+			seen[next] = true
+		}
+	}
+	return nil
+}
+
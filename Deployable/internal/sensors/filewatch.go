@@ -0,0 +1,288 @@
+package sensors
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"deployable/internal/types"
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultSettle = 500 * time.Millisecond
+
+func init() {
+	RegisterDriver("file_watch", newFileWatchSensor)
+}
+
+// FileWatchSensor reports files created, modified or deleted in a
+// directory. Bursts of filesystem events for the same file are coalesced
+// until the file has been quiet for the settle delay, so a large image
+// being copied in produces a single created event once the copy finishes.
+type FileWatchSensor struct {
+	id       string
+	dir      string
+	patterns []string
+	settle   time.Duration
+	events   map[string]bool
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	pending map[string]*pendingChange
+	stop    chan struct{}
+	report  HealthReporter
+}
+
+type pendingChange struct {
+	kind  string
+	timer *time.Timer
+}
+
+func newFileWatchSensor(cfg types.SensorConfig) (Sensor, error) {
+	dir, _ := cfg.Config["path"].(string)
+	if dir == "" {
+		return nil, errors.New("file_watch sensor requires config.path")
+	}
+	patterns, err := stringList(cfg.Config["patterns"])
+	if err != nil {
+		return nil, fmt.Errorf("file_watch patterns: %w", err)
+	}
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("file_watch pattern %q invalid: %w", pattern, err)
+		}
+	}
+	settle := defaultSettle
+	if ms, ok := cfg.Config["settle_ms"].(float64); ok && ms >= 0 {
+		settle = time.Duration(ms) * time.Millisecond
+	}
+	kinds, err := stringList(cfg.Config["events"])
+	if err != nil {
+		return nil, fmt.Errorf("file_watch events: %w", err)
+	}
+	if len(kinds) == 0 {
+		kinds = []string{"created", "modified", "deleted"}
+	}
+	events := map[string]bool{}
+	for _, kind := range kinds {
+		switch kind {
+		case "created", "modified", "deleted":
+			events[kind] = true
+		default:
+			return nil, fmt.Errorf("file_watch unknown event %q", kind)
+		}
+	}
+	return &FileWatchSensor{
+		id:       cfg.SensorID,
+		dir:      filepath.Clean(dir),
+		patterns: patterns,
+		settle:   settle,
+		events:   events,
+	}, nil
+}
+
+func (s *FileWatchSensor) ID() string {
+	return s.id
+}
+
+func (s *FileWatchSensor) Type() string {
+	return "file_watch"
+}
+
+func (s *FileWatchSensor) Capabilities() map[string]any {
+	events := make([]string, 0, len(s.events))
+	for _, kind := range []string{"created", "modified", "deleted"} {
+		if s.events[kind] {
+			events = append(events, kind)
+		}
+	}
+	return map[string]any{
+		"path":      s.dir,
+		"patterns":  s.patterns,
+		"settle_ms": s.settle.Milliseconds(),
+		"events":    events,
+	}
+}
+
+func (s *FileWatchSensor) Start(eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher != nil {
+		return
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("sensor %s: watcher failed: %v", s.id, err)
+		s.reportHealth(err, true)
+		return
+	}
+	if err := watcher.Add(s.dir); err != nil {
+		log.Printf("sensor %s: cannot watch %s: %v", s.id, s.dir, err)
+		_ = watcher.Close()
+		s.reportHealth(err, true)
+		return
+	}
+	s.watcher = watcher
+	s.pending = make(map[string]*pendingChange)
+	s.stop = make(chan struct{})
+	go s.run(watcher, s.stop, eventSink)
+}
+
+func (s *FileWatchSensor) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher == nil {
+		return
+	}
+	close(s.stop)
+	_ = s.watcher.Close()
+	for _, change := range s.pending {
+		change.timer.Stop()
+	}
+	s.watcher = nil
+	s.pending = nil
+}
+
+func (s *FileWatchSensor) SetHealthReporter(report HealthReporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report = report
+}
+
+func (s *FileWatchSensor) reportHealth(err error, offline bool) {
+	if s.report != nil {
+		s.report(err, offline)
+	}
+}
+
+func (s *FileWatchSensor) run(watcher *fsnotify.Watcher, stop <-chan struct{}, eventSink chan<- types.SensorEvent) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			s.observe(event, stop, eventSink)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("sensor %s: watch error: %v", s.id, err)
+			s.mu.Lock()
+			s.reportHealth(err, false)
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *FileWatchSensor) observe(event fsnotify.Event, stop <-chan struct{}, eventSink chan<- types.SensorEvent) {
+	name := filepath.Base(event.Name)
+	if !s.matches(name) {
+		return
+	}
+	var kind string
+	switch {
+	case event.Has(fsnotify.Create):
+		kind = "created"
+	case event.Has(fsnotify.Write):
+		kind = "modified"
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		kind = "deleted"
+	default:
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == nil {
+		return
+	}
+	change, exists := s.pending[event.Name]
+	if !exists {
+		change = &pendingChange{kind: kind}
+		s.pending[event.Name] = change
+		path := event.Name
+		change.timer = time.AfterFunc(s.settle, func() {
+			s.flush(path, stop, eventSink)
+		})
+		return
+	}
+	switch {
+	case change.kind == "created" && kind == "deleted":
+		change.timer.Stop()
+		delete(s.pending, event.Name)
+		return
+	case change.kind == "created":
+	case change.kind == "deleted" && kind == "created":
+		change.kind = "modified"
+	default:
+		change.kind = kind
+	}
+	change.timer.Reset(s.settle)
+}
+
+func (s *FileWatchSensor) flush(path string, stop <-chan struct{}, eventSink chan<- types.SensorEvent) {
+	s.mu.Lock()
+	change, ok := s.pending[path]
+	if ok {
+		delete(s.pending, path)
+	}
+	s.mu.Unlock()
+	if !ok || !s.events[change.kind] {
+		return
+	}
+	if change.kind != "deleted" {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return
+		}
+	}
+	select {
+	case <-stop:
+	case eventSink <- types.SensorEvent{
+		SensorID:   s.id,
+		SensorType: "file_watch",
+		EventType:  change.kind,
+		Value:      filepath.Base(path),
+		Timestamp:  time.Now().UTC(),
+	}:
+	}
+}
+
+func (s *FileWatchSensor) matches(name string) bool {
+	if len(s.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range s.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(raw any) ([]string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []any:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected string, got %v", item)
+			}
+			out = append(out, text)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("expected string or list, got %v", raw)
+	}
+}
+
@@ -0,0 +1,77 @@
+package sensors
+
+import (
+	"time"
+
+	"deployable/internal/types"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusOffline  = "offline"
+
+	EventSensorOffline = "sensor_offline"
+	EventSensorOnline  = "sensor_online"
+
+	degradedWindow = time.Minute
+	staleCheck     = 250 * time.Millisecond
+)
+
+// HealthReporter lets a driver report failures the manager cannot see from
+// its events, such as a watch that could not be set up. offline marks the
+// sensor offline until its next event.
+type HealthReporter func(err error, offline bool)
+
+// HealthAware is implemented by sensors that report their own failures.
+type HealthAware interface {
+	SetHealthReporter(report HealthReporter)
+}
+
+type health struct {
+	sensorType  string
+	status      string
+	since       time.Time
+	lastEvent   time.Time
+	lastErrorAt time.Time
+	lastError   string
+	errorCount  int
+	heartbeat   time.Duration
+	forced      bool
+}
+
+func (h *health) evaluate(now time.Time) string {
+	if h.forced {
+		return StatusOffline
+	}
+	if h.heartbeat > 0 {
+		last := h.lastEvent
+		if last.IsZero() {
+			last = h.since
+		}
+		if now.Sub(last) > h.heartbeat {
+			return StatusOffline
+		}
+	}
+	if !h.lastErrorAt.IsZero() && now.Sub(h.lastErrorAt) < degradedWindow && h.lastErrorAt.After(h.lastEvent) {
+		return StatusDegraded
+	}
+	return StatusOK
+}
+
+func (h *health) report(id string) types.SensorHealth {
+	report := types.SensorHealth{
+		SensorID:    id,
+		SensorType:  h.sensorType,
+		Status:      h.status,
+		ErrorCount:  h.errorCount,
+		LastError:   h.lastError,
+		HeartbeatMs: h.heartbeat.Milliseconds(),
+	}
+	if !h.lastEvent.IsZero() {
+		last := h.lastEvent.UTC()
+		report.LastEvent = &last
+	}
+	return report
+}
+
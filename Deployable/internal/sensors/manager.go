@@ -2,6 +2,7 @@ package sensors
 
 import (
 	"sync"
+	"time"
 
 	"deployable/internal/types"
 )
@@ -15,31 +16,102 @@ type Sensor interface {
 }
 
 type Manager struct {
-	mu       sync.Mutex
-	sensors  []Sensor
-	eventOut chan types.SensorEvent
-	running  bool
+	mu         sync.Mutex
+	sensors    []entry
+	health     map[string]*health
+	heartbeats map[string]time.Duration
+	in         chan types.SensorEvent
+	eventOut   chan types.SensorEvent
+	running    bool
+	onHealth   func(types.SensorHealth)
 }
 
+type entry struct {
+	group  string
+	sensor Sensor
+}
+
+type healthChange struct {
+	id     string
+	from   string
+	report types.SensorHealth
+}
+
+// NewManager relays every sensor event to eventOut, tracking per-sensor
+// health on the way through.
 func NewManager(eventOut chan types.SensorEvent) *Manager {
-	return &Manager{eventOut: eventOut}
+	m := &Manager{
+		eventOut:   eventOut,
+		in:         make(chan types.SensorEvent, cap(eventOut)),
+		health:     make(map[string]*health),
+		heartbeats: make(map[string]time.Duration),
+	}
+	go m.relay()
+	go m.watchStale()
+	return m
 }
 
 func (m *Manager) Add(sensor Sensor) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.sensors = append(m.sensors, sensor)
+	m.sensors = append(m.sensors, entry{sensor: sensor})
+	m.trackLocked(sensor)
 	if m.running {
-		sensor.Start(m.eventOut)
+		m.startLocked(sensor)
 	}
 }
 
+// SetGroup replaces every sensor previously registered under group,
+// stopping the old set and starting the new one if the manager is running.
+func (m *Manager) SetGroup(group string, sensors []Sensor) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.sensors[:0]
+	for _, item := range m.sensors {
+		if item.group != group {
+			kept = append(kept, item)
+			continue
+		}
+		if m.running {
+			item.sensor.Stop()
+		}
+		delete(m.health, item.sensor.ID())
+	}
+	m.sensors = kept
+	for _, sensor := range sensors {
+		m.sensors = append(m.sensors, entry{group: group, sensor: sensor})
+		m.trackLocked(sensor)
+		if m.running {
+			m.startLocked(sensor)
+		}
+	}
+}
+
+// SetHeartbeats configures how long each sensor may stay silent before it
+// is reported offline. Sensors missing from the map have no heartbeat.
+func (m *Manager) SetHeartbeats(heartbeats map[string]time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heartbeats = heartbeats
+	for id, h := range m.health {
+		h.heartbeat = heartbeats[id]
+	}
+}
+
+// OnHealthChange registers a callback invoked whenever a sensor's status
+// changes between ok, degraded and offline.
+func (m *Manager) OnHealthChange(fn func(types.SensorHealth)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onHealth = fn
+}
+
 func (m *Manager) Start() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.running = true
-	for _, sensor := range m.sensors {
-		sensor.Start(m.eventOut)
+	for _, item := range m.sensors {
+		m.startLocked(item.sensor)
 	}
 }
 
@@ -47,8 +119,8 @@ func (m *Manager) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.running = false
-	for _, sensor := range m.sensors {
-		sensor.Stop()
+	for _, item := range m.sensors {
+		item.sensor.Stop()
 	}
 }
 
@@ -56,17 +128,200 @@ func (m *Manager) Snapshot() []map[string]any {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	out := make([]map[string]any, 0, len(m.sensors))
-	for _, sensor := range m.sensors {
-		out = append(out, map[string]any{
-			"id":           sensor.ID(),
-			"type":         sensor.Type(),
-			"capabilities": sensor.Capabilities(),
-		})
+	for _, item := range m.sensors {
+		id := item.sensor.ID()
+		snapshot := map[string]any{
+			"id":           id,
+			"type":         item.sensor.Type(),
+			"source":       item.group,
+			"running":      m.running,
+			"capabilities": item.sensor.Capabilities(),
+		}
+		if h, ok := m.health[id]; ok {
+			snapshot["health"] = h.report(id)
+		}
+		out = append(out, snapshot)
 	}
 	return out
 }
 
+func (m *Manager) HealthSnapshot() []types.SensorHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]types.SensorHealth, 0, len(m.sensors))
+	for _, item := range m.sensors {
+		id := item.sensor.ID()
+		if h, ok := m.health[id]; ok {
+			out = append(out, h.report(id))
+		}
+	}
+	return out
+}
+
+func (m *Manager) Lookup(id string) (Sensor, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range m.sensors {
+		if item.sensor.ID() == id {
+			return item.sensor, true
+		}
+	}
+	return nil, false
+}
+
+func (m *Manager) Inject(event types.SensorEvent) {
+	m.in <- event
+}
+
 func (m *Manager) EventChannel() chan types.SensorEvent {
 	return m.eventOut
 }
 
+func (m *Manager) trackLocked(sensor Sensor) {
+	id := sensor.ID()
+	m.health[id] = &health{
+		sensorType: sensor.Type(),
+		status:     StatusOK,
+		since:      time.Now(),
+		heartbeat:  m.heartbeats[id],
+	}
+	if aware, ok := sensor.(HealthAware); ok {
+		// Drivers may report while holding their own lock, possibly from
+		// inside Start, so the update is applied off the caller's stack.
+		aware.SetHealthReporter(func(err error, offline bool) {
+			go m.reportError(id, err, offline)
+		})
+	}
+}
+
+func (m *Manager) startLocked(sensor Sensor) {
+	if h, ok := m.health[sensor.ID()]; ok {
+		h.since = time.Now()
+	}
+	sensor.Start(m.in)
+}
+
+// maxDerivedDepth bounds chains of derived sensors fed from one event.
+const maxDerivedDepth = 8
+
+func (m *Manager) relay() {
+	for event := range m.in {
+		m.dispatch(event, 0)
+	}
+}
+
+func (m *Manager) dispatch(event types.SensorEvent, depth int) {
+	m.mu.Lock()
+	var changes []healthChange
+	if h, ok := m.health[event.SensorID]; ok {
+		h.lastEvent = time.Now()
+		h.forced = false
+		changes = m.evaluateLocked(time.Now())
+	}
+	notify := m.onHealth
+	var derivers []Deriver
+	for _, item := range m.sensors {
+		if d, ok := item.sensor.(Deriver); ok && d.Source() == event.SensorID {
+			derivers = append(derivers, d)
+		}
+	}
+	m.mu.Unlock()
+	m.publish(changes, notify)
+	m.eventOut <- event
+	if depth >= maxDerivedDepth {
+		return
+	}
+	for _, d := range derivers {
+		if derived, ok := d.Derive(event); ok {
+			m.dispatch(derived, depth+1)
+		}
+	}
+}
+
+func (m *Manager) reportError(id string, err error, offline bool) {
+	m.mu.Lock()
+	h, ok := m.health[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	h.errorCount++
+	h.lastErrorAt = time.Now()
+	if err != nil {
+		h.lastError = err.Error()
+	}
+	if offline {
+		h.forced = true
+	}
+	changes := m.evaluateLocked(time.Now())
+	notify := m.onHealth
+	m.mu.Unlock()
+	m.publish(changes, notify)
+}
+
+func (m *Manager) watchStale() {
+	ticker := time.NewTicker(staleCheck)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		m.mu.Lock()
+		if !m.running {
+			m.mu.Unlock()
+			continue
+		}
+		changes := m.evaluateLocked(now)
+		notify := m.onHealth
+		m.mu.Unlock()
+		m.publish(changes, notify)
+	}
+}
+
+func (m *Manager) evaluateLocked(now time.Time) []healthChange {
+	var changes []healthChange
+	for _, item := range m.sensors {
+		id := item.sensor.ID()
+		h, ok := m.health[id]
+		if !ok {
+			continue
+		}
+		status := h.evaluate(now)
+		if status == h.status {
+			continue
+		}
+		from := h.status
+		h.status = status
+		changes = append(changes, healthChange{id: id, from: from, report: h.report(id)})
+	}
+	return changes
+}
+
+// publish emits sensor_offline and sensor_online events for transitions
+// into and out of offline, so show logic can react to them like any other
+// sensor event.
+func (m *Manager) publish(changes []healthChange, notify func(types.SensorHealth)) {
+	for _, change := range changes {
+		status := change.report.Status
+		eventType := ""
+		if status == StatusOffline && change.from != StatusOffline {
+			eventType = EventSensorOffline
+		} else if change.from == StatusOffline && status != StatusOffline {
+			eventType = EventSensorOnline
+		}
+		if eventType != "" {
+			reason := change.report.LastError
+			if eventType == EventSensorOffline && reason == "" {
+				reason = "heartbeat missed"
+			}
+			m.eventOut <- types.SensorEvent{
+				SensorID:   change.id,
+				SensorType: change.report.SensorType,
+				EventType:  eventType,
+				Value:      reason,
+				Timestamp:  time.Now().UTC(),
+			}
+		}
+		if notify != nil {
+			notify(change.report)
+		}
+	}
+}
+
@@ -24,6 +24,7 @@ type HelloMessage struct {
 	ProfileVersion    int                    `json:"assigned_profile_version,omitempty"`
 	ShowLogicVersion  int                    `json:"assigned_show_logic_version,omitempty"`
 	Capabilities      types.CapabilityReport `json:"capabilities"`
+	Sensors           []types.SensorHealth   `json:"sensors,omitempty"`
 }
 
 type IdentifyMessage struct {
@@ -39,12 +40,62 @@ type AssignRoleMessage struct {
 }
 
 type StateUpdateMessage struct {
+	Type        string     `json:"type"`
+	State       string     `json:"state"`
+	Version     int        `json:"version"`
+	Timestamp   time.Time  `json:"timestamp"`
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
+}
+
+// ClockPingMessage asks the server for its time; the server answers with
+// a ClockPongMessage echoing PingID.
+type ClockPingMessage struct {
+	Type       string    `json:"type"`
+	DeviceID   string    `json:"device_id"`
+	PingID     int64     `json:"ping_id"`
+	ClientTime time.Time `json:"client_time"`
+}
+
+type ClockPongMessage struct {
+	Type       string    `json:"type"`
+	PingID     int64     `json:"ping_id"`
+	ClientTime time.Time `json:"client_time"`
+	ServerTime time.Time `json:"server_time"`
+}
+
+type RequestStateMessage struct {
 	Type      string    `json:"type"`
+	DeviceID  string    `json:"device_id"`
+	RequestID string    `json:"request_id"`
 	State     string    `json:"state"`
-	Version   int       `json:"version"`
+	Reason    string    `json:"reason,omitempty"`
+	FromState string    `json:"from_state,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+type RequestStateAck struct {
+	Type      string `json:"type"`
+	RequestID string `json:"request_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type VoteChoice struct {
+	Label       string `json:"label"`
+	NextStateID string `json:"next_state_id,omitempty"`
+}
+
+type VoteUpdateMessage struct {
+	Type             string       `json:"type"`
+	ForkID           string       `json:"fork_id"`
+	Choices          []VoteChoice `json:"choices"`
+	Counts           []int        `json:"counts"`
+	CountdownSeconds int          `json:"countdown_seconds"`
+	RemainingMs      int          `json:"remaining_ms"`
+	Closed           bool         `json:"closed"`
+	Timestamp        time.Time    `json:"timestamp"`
+}
+
 type AssignRoleAck struct {
 	Type     string `json:"type"`
 	DeviceID string `json:"device_id"`
@@ -64,6 +115,13 @@ type SensorEventMessage struct {
 	types.SensorEvent
 }
 
+type SensorHealthMessage struct {
+	Type      string               `json:"type"`
+	DeviceID  string               `json:"device_id"`
+	Sensors   []types.SensorHealth `json:"sensors"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
 type PlaybackErrorMessage struct {
 	Type      string             `json:"type"`
 	DeviceID  string             `json:"device_id"`
@@ -72,6 +130,13 @@ type PlaybackErrorMessage struct {
 	Timestamp time.Time          `json:"timestamp"`
 }
 
+type PlaybackStatusMessage struct {
+	Type      string                 `json:"type"`
+	DeviceID  string                 `json:"device_id"`
+	Outputs   []types.PlaybackStatus `json:"outputs"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
 type Client struct {
 	ServerURL string
 }
@@ -138,6 +203,30 @@ func (c *Client) Run(ctx context.Context, helloProvider func() HelloMessage, inc
 						continue
 					}
 					incoming <- Incoming{RawType: msgType, Payload: msg}
+				case "vote_update":
+					data, _ := json.Marshal(envelope)
+					var msg VoteUpdateMessage
+					if err := json.Unmarshal(data, &msg); err != nil {
+						log.Printf("vote_update parse failed: %v", err)
+						continue
+					}
+					incoming <- Incoming{RawType: msgType, Payload: msg}
+				case "clock_pong":
+					data, _ := json.Marshal(envelope)
+					var msg ClockPongMessage
+					if err := json.Unmarshal(data, &msg); err != nil {
+						log.Printf("clock_pong parse failed: %v", err)
+						continue
+					}
+					incoming <- Incoming{RawType: msgType, Payload: msg}
+				case "request_state_ack":
+					data, _ := json.Marshal(envelope)
+					var msg RequestStateAck
+					if err := json.Unmarshal(data, &msg); err != nil {
+						log.Printf("request_state_ack parse failed: %v", err)
+						continue
+					}
+					incoming <- Incoming{RawType: msgType, Payload: msg}
 				default:
 					log.Printf("unknown message type: %s", msgType)
 				}
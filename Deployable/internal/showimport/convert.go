@@ -0,0 +1,253 @@
+package showimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"deployable/internal/types"
+)
+
+const defaultDMXAction = "dmx.set"
+
+// Mapping assigns Editor outputs and audience media to device roles.
+type Mapping struct {
+	Roles map[string]RoleMapping `json:"roles"`
+}
+
+type RoleMapping struct {
+	// Outputs lists Editor output IDs driven by this role; "*" selects all.
+	Outputs []string `json:"outputs"`
+	// MediaTarget, when set, plays each state's audience media on that
+	// playback output.
+	MediaTarget string `json:"media_target,omitempty"`
+	OSCTarget   string `json:"osc_target,omitempty"`
+	MQTTBroker  string `json:"mqtt_broker,omitempty"`
+	// DMXAction names the action DMX messages become, normally provided
+	// by a plugin. Defaults to dmx.set.
+	DMXAction string `json:"dmx_action,omitempty"`
+}
+
+type Result struct {
+	Role      string                    `json:"role"`
+	ShowLogic types.ShowLogicDefinition `json:"show_logic"`
+	Assets    []string                  `json:"assets"`
+	Warnings  []string                  `json:"warnings,omitempty"`
+}
+
+func LoadMapping(filename string) (Mapping, error) {
+	var mapping Mapping
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return mapping, err
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return mapping, fmt.Errorf("role mapping: %w", err)
+	}
+	if len(mapping.Roles) == 0 {
+		return mapping, errors.New("role mapping has no roles")
+	}
+	return mapping, nil
+}
+
+// Convert builds one show logic definition per mapped role. Every Editor
+// state becomes a show state of the same ID, so the conductor's state IDs
+// drive the engine directly. Entering a state sends its outputs' messages
+// and starts its media; leaving it stops the media.
+func Convert(export *Export, mapping Mapping, version int) ([]Result, error) {
+	outputs := map[string]Output{}
+	for _, output := range export.Outputs {
+		outputs[output.ID] = output
+	}
+	claimed := map[string]bool{}
+	roles := make([]string, 0, len(mapping.Roles))
+	for role := range mapping.Roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	results := make([]Result, 0, len(roles))
+	for _, role := range roles {
+		cfg := mapping.Roles[role]
+		selected := map[string]bool{}
+		for _, id := range cfg.Outputs {
+			if id == "*" {
+				for outputID := range outputs {
+					selected[outputID] = true
+				}
+				continue
+			}
+			if _, ok := outputs[id]; !ok {
+				return nil, fmt.Errorf("role %s maps unknown output %s", role, id)
+			}
+			selected[id] = true
+		}
+		for id := range selected {
+			claimed[id] = true
+		}
+		result, err := convertRole(export, role, cfg, outputs, selected, version)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	for _, output := range export.Outputs {
+		if !claimed[output.ID] {
+			for i := range results {
+				results[i].Warnings = append(results[i].Warnings, fmt.Sprintf("output %s (%s) is not mapped to any role", output.ID, output.Type))
+			}
+		}
+	}
+	return results, nil
+}
+
+func convertRole(export *Export, role string, cfg RoleMapping, outputs map[string]Output, selected map[string]bool, version int) (Result, error) {
+	result := Result{
+		Role: role,
+		ShowLogic: types.ShowLogicDefinition{
+			LogicID: logicID(export.Show.ShowName, role),
+			Version: version,
+		},
+		Assets: []string{},
+	}
+	assets := map[string]bool{}
+	warned := map[string]bool{}
+	warn := func(format string, args ...any) {
+		message := fmt.Sprintf(format, args...)
+		if !warned[message] {
+			warned[message] = true
+			result.Warnings = append(result.Warnings, message)
+		}
+	}
+	for _, stateID := range export.stateOrder() {
+		node := export.Nodes[stateID]
+		state := types.ShowState{
+			Name:           stateID,
+			OnEnter:        []types.ActionTemplate{},
+			OnExit:         []types.ActionTemplate{},
+			SensorHandlers: []types.SensorHandler{},
+			TimerHandlers:  []types.TimerHandler{},
+			Timers:         []types.TimerDeclaration{},
+		}
+		for _, output := range stateOutputs(node, export.Outputs) {
+			if !selected[output.ID] {
+				continue
+			}
+			actions, err := outputActions(output, cfg, warn)
+			if err != nil {
+				return result, fmt.Errorf("state %s: %w", stateID, err)
+			}
+			state.OnEnter = append(state.OnEnter, actions...)
+		}
+		if cfg.MediaTarget != "" && len(node.AudienceMedia) > 0 {
+			media := node.AudienceMedia[0]
+			if len(node.AudienceMedia) > 1 {
+				warn("state %s has %d media files; only %s is played", stateID, len(node.AudienceMedia), media.File)
+			}
+			file := strings.TrimPrefix(path.Clean(media.File), "assets/")
+			state.OnEnter = append(state.OnEnter, types.ActionTemplate{
+				Action: "play_video",
+				Target: cfg.MediaTarget,
+				Params: map[string]any{
+					"file": file,
+					"loop": media.Type == "image",
+				},
+			})
+			state.OnExit = append(state.OnExit, types.ActionTemplate{
+				Action: "stop_video",
+				Target: cfg.MediaTarget,
+				Params: map[string]any{},
+			})
+			assets[file] = true
+		}
+		result.ShowLogic.States = append(result.ShowLogic.States, state)
+	}
+	for asset := range assets {
+		result.Assets = append(result.Assets, asset)
+	}
+	sort.Strings(result.Assets)
+	return result, nil
+}
+
+// stateOutputs returns the outputs attached to a state, either through the
+// output's sceneId or the state's outputIds, in export order.
+func stateOutputs(node Node, all []Output) []Output {
+	listed := map[string]bool{}
+	for _, id := range node.OutputIDs {
+		listed[id] = true
+	}
+	var out []Output
+	for _, output := range all {
+		if output.SceneID == node.ID || listed[output.ID] {
+			out = append(out, output)
+		}
+	}
+	return out
+}
+
+func outputActions(output Output, cfg RoleMapping, warn func(string, ...any)) ([]types.ActionTemplate, error) {
+	actions := make([]types.ActionTemplate, 0, len(output.Messages))
+	for _, msg := range output.Messages {
+		if msg.Path == "" {
+			return nil, fmt.Errorf("output %s has a message without path", output.ID)
+		}
+		switch strings.ToUpper(output.Type) {
+		case "OSC":
+			if cfg.OSCTarget == "" {
+				return nil, fmt.Errorf("output %s is OSC but the role has no osc_target", output.ID)
+			}
+			actions = append(actions, types.ActionTemplate{
+				Action: "osc.send",
+				Target: cfg.OSCTarget,
+				Params: map[string]any{
+					"address": msg.Path,
+					"args":    []any{msg.Value},
+				},
+			})
+		case "MQTT":
+			params := map[string]any{
+				"topic":   msg.Path,
+				"payload": msg.Value,
+			}
+			if cfg.MQTTBroker != "" {
+				params["broker"] = cfg.MQTTBroker
+			}
+			actions = append(actions, types.ActionTemplate{
+				Action: "mqtt.publish",
+				Params: params,
+			})
+		case "DMX":
+			action := cfg.DMXAction
+			if action == "" {
+				action = defaultDMXAction
+				warn("DMX output %s uses %s, which must be provided by a plugin", output.ID, action)
+			}
+			actions = append(actions, types.ActionTemplate{
+				Action: action,
+				Params: map[string]any{
+					"channel": msg.Path,
+					"value":   msg.Value,
+				},
+			})
+		default:
+			return nil, fmt.Errorf("output %s has unsupported type %q", output.ID, output.Type)
+		}
+	}
+	return actions, nil
+}
+
+var unsafeID = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func logicID(showName, role string) string {
+	name := strings.Trim(unsafeID.ReplaceAllString(strings.ToLower(showName), "-"), "-")
+	if name == "" {
+		name = "show"
+	}
+	return name + "-" + role
+}
+
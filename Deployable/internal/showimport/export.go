@@ -0,0 +1,167 @@
+package showimport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Export is the part of the Editor's show.json that the converter reads.
+// Both the packaged format (nodes keyed by ID) and the raw project format
+// (states array) are accepted.
+type Export struct {
+	Show    ExportShow      `json:"show"`
+	Nodes   map[string]Node `json:"nodes"`
+	States  []Node          `json:"states"`
+	Outputs []Output        `json:"outputs"`
+
+	// zip is set when the export was read from a package, so assets can
+	// be extracted from it.
+	zip *zip.ReadCloser
+}
+
+type ExportShow struct {
+	ShowName       string `json:"showName"`
+	Version        string `json:"version"`
+	InitialStateID string `json:"initialStateId"`
+}
+
+type Node struct {
+	ID               string          `json:"id"`
+	Type             string          `json:"type"`
+	Title            string          `json:"title"`
+	AudienceMedia    []AudienceMedia `json:"audienceMedia"`
+	OutputIDs        []string        `json:"outputIds"`
+	CountdownSeconds int             `json:"countdownSeconds"`
+	Choices          []Choice        `json:"choices"`
+}
+
+type AudienceMedia struct {
+	Type string `json:"type"`
+	File string `json:"file"`
+	Size int64  `json:"size"`
+}
+
+type Choice struct {
+	Label       string `json:"label"`
+	NextStateID string `json:"nextStateId"`
+}
+
+type Output struct {
+	ID       string          `json:"id"`
+	SceneID  string          `json:"sceneId"`
+	Type     string          `json:"type"`
+	Messages []OutputMessage `json:"messages"`
+}
+
+type OutputMessage struct {
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// Load reads an Editor export, either the exported .zip package or a bare
+// show.json.
+func Load(filename string) (*Export, error) {
+	if strings.EqualFold(path.Ext(filename), ".zip") {
+		archive, err := zip.OpenReader(filename)
+		if err != nil {
+			return nil, err
+		}
+		file, err := archive.Open("show.json")
+		if err != nil {
+			archive.Close()
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		export, err := decodeExport(file)
+		file.Close()
+		if err != nil {
+			archive.Close()
+			return nil, err
+		}
+		export.zip = archive
+		return export, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decodeExport(file)
+}
+
+func decodeExport(r io.Reader) (*Export, error) {
+	var export Export
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("show export: %w", err)
+	}
+	if export.Nodes == nil {
+		export.Nodes = make(map[string]Node, len(export.States))
+	}
+	for _, node := range export.States {
+		if _, exists := export.Nodes[node.ID]; !exists {
+			export.Nodes[node.ID] = node
+		}
+	}
+	export.States = nil
+	if len(export.Nodes) == 0 {
+		return nil, errors.New("show export has no states")
+	}
+	for id, node := range export.Nodes {
+		if node.ID == "" {
+			node.ID = id
+			export.Nodes[id] = node
+		}
+	}
+	return &export, nil
+}
+
+func (e *Export) Close() error {
+	if e.zip != nil {
+		return e.zip.Close()
+	}
+	return nil
+}
+
+// OpenAsset opens a media file from the export package. It fails for
+// exports loaded from a bare show.json.
+func (e *Export) OpenAsset(name string) (io.ReadCloser, error) {
+	if e.zip == nil {
+		return nil, errors.New("export is not a package")
+	}
+	return e.zip.Open(path.Join("assets", name))
+}
+
+// stateOrder lists state IDs with the initial state first and the rest
+// sorted, since offline mode starts at the first state.
+func (e *Export) stateOrder() []string {
+	ids := make([]string, 0, len(e.Nodes))
+	for id := range e.Nodes {
+		if id != e.initialState() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if initial := e.initialState(); initial != "" {
+		ids = append([]string{initial}, ids...)
+	}
+	return ids
+}
+
+func (e *Export) initialState() string {
+	if _, ok := e.Nodes[e.Show.InitialStateID]; ok {
+		return e.Show.InitialStateID
+	}
+	for id, node := range e.Nodes {
+		if node.Type == "opening" {
+			return id
+		}
+	}
+	return ""
+}
+
@@ -40,9 +40,49 @@ type OutputCapability struct {
 }
 
 type ExecutionProfile struct {
-	ProfileID string         `json:"profile_id"`
-	Version   int            `json:"version"`
-	Requires  map[string]any `json:"requires"`
+	ProfileID    string               `json:"profile_id"`
+	Version      int                  `json:"version"`
+	Requires     map[string]any       `json:"requires"`
+	SensorTokens map[string]string    `json:"sensor_tokens,omitempty"`
+	MQTT         []MQTTBroker         `json:"mqtt,omitempty"`
+	Commands     []CommandDeclaration `json:"commands,omitempty"`
+	Sensors      []SensorConfig       `json:"sensors,omitempty"`
+}
+
+type SensorConfig struct {
+	SensorID    string         `json:"sensor_id"`
+	Driver      string         `json:"driver"`
+	Config      map[string]any `json:"config,omitempty"`
+	HeartbeatMs int            `json:"heartbeat_ms,omitempty"`
+}
+
+type CommandDeclaration struct {
+	CommandID string            `json:"command_id"`
+	Program   string            `json:"program"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	WorkDir   string            `json:"work_dir,omitempty"`
+	TimeoutMs int               `json:"timeout_ms,omitempty"`
+}
+
+type MQTTBroker struct {
+	BrokerID        string             `json:"broker_id"`
+	URL             string             `json:"url"`
+	ClientID        string             `json:"client_id,omitempty"`
+	Username        string             `json:"username,omitempty"`
+	Password        string             `json:"password,omitempty"`
+	ProtocolVersion int                `json:"protocol_version,omitempty"`
+	KeepAliveSec    int                `json:"keep_alive_sec,omitempty"`
+	CleanSession    *bool              `json:"clean_session,omitempty"`
+	MaxPacketBytes  int                `json:"max_packet_bytes,omitempty"`
+	Subscriptions   []MQTTSubscription `json:"subscriptions,omitempty"`
+}
+
+type MQTTSubscription struct {
+	SensorID  string `json:"sensor_id"`
+	Topic     string `json:"topic"`
+	QoS       int    `json:"qos"`
+	EventType string `json:"event_type,omitempty"`
 }
 
 type ShowLogicDefinition struct {
@@ -57,6 +97,10 @@ type ShowState struct {
 	OnEnter []ActionTemplate `json:"on_enter"`
 	OnExit  []ActionTemplate `json:"on_exit"`
 
+	// CrossfadeMs makes transitions into this state crossfade the media
+	// it plays from whatever the outputs were playing, instead of cutting.
+	CrossfadeMs int `json:"crossfade_ms,omitempty"`
+
 	SensorHandlers []SensorHandler `json:"sensor_handlers"`
 	TimerHandlers  []TimerHandler  `json:"timer_handlers"`
 
@@ -90,6 +134,9 @@ type GlobalStateUpdate struct {
 	State     string    `json:"state"`
 	Version   int       `json:"version"`
 	Timestamp time.Time `json:"timestamp"`
+	// EffectiveAt is when, on the State Server's clock, the state's media
+	// should start.
+	EffectiveAt *time.Time `json:"effective_at,omitempty"`
 }
 
 type TimerEvent struct {
@@ -106,6 +153,36 @@ type SensorEvent struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+type SensorHealth struct {
+	SensorID    string     `json:"sensor_id"`
+	SensorType  string     `json:"sensor_type"`
+	Status      string     `json:"status"`
+	LastEvent   *time.Time `json:"last_event,omitempty"`
+	ErrorCount  int        `json:"error_count"`
+	LastError   string     `json:"last_error,omitempty"`
+	HeartbeatMs int64      `json:"heartbeat_ms,omitempty"`
+}
+
+// PlaybackStatus is the state of one playback output. Position and
+// duration come from the backend when it can report them and are
+// estimated from the commands sent otherwise.
+type PlaybackStatus struct {
+	OutputID    string     `json:"output_id"`
+	Active      bool       `json:"active"`
+	Asset       string     `json:"asset,omitempty"`
+	Playing     bool       `json:"playing"`
+	Paused      bool       `json:"paused"`
+	Prepared    bool       `json:"prepared,omitempty"`
+	Loop        bool       `json:"loop"`
+	Volume      float64    `json:"volume"`
+	PositionMs  int        `json:"position_ms"`
+	DurationMs  int        `json:"duration_ms,omitempty"`
+	SyncGroup   string     `json:"sync_group,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
 type EngineAction struct {
 	Action string         `json:"action"`
 	Target string         `json:"target"`
@@ -0,0 +1,204 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"nhooyr.io/websocket"
+
+	"deployable/internal/types"
+)
+
+type panelMessage struct {
+	Type      string `json:"type"`
+	SensorID  string `json:"sensor_id"`
+	EventType string `json:"event_type"`
+	Value     any    `json:"value"`
+}
+
+func (s *Server) handlePanel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(panelPage))
+}
+
+func (s *Server) handlePanelSensors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Runtime.PanelSensors())
+}
+
+// handlePanelSocket injects the events sent by the panel page. The default
+// origin check of websocket.Accept keeps other sites from driving it.
+func (s *Server) handlePanelSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	ctx := r.Context()
+	if err := writePanelJSON(ctx, conn, map[string]any{"type": "sensors", "sensors": s.Runtime.PanelSensors()}); err != nil {
+		return
+	}
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			if websocket.CloseStatus(err) == -1 && !errors.Is(err, context.Canceled) {
+				log.Printf("sensor panel read failed: %v", err)
+			}
+			return
+		}
+		var msg panelMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			_ = writePanelJSON(ctx, conn, map[string]any{"type": "error", "error": "invalid json: " + err.Error()})
+			continue
+		}
+		var reply any
+		switch msg.Type {
+		case "list":
+			reply = map[string]any{"type": "sensors", "sensors": s.Runtime.PanelSensors()}
+		case "event":
+			if msg.SensorID == "" {
+				reply = map[string]any{"type": "error", "error": "sensor_id required"}
+				break
+			}
+			if msg.EventType == "" {
+				msg.EventType = "trigger"
+			}
+			event := types.SensorEvent{
+				SensorID:   msg.SensorID,
+				SensorType: "panel",
+				EventType:  msg.EventType,
+				Value:      msg.Value,
+				Timestamp:  time.Now().UTC(),
+			}
+			s.Runtime.InjectSensorEvent(event)
+			reply = map[string]any{"type": "sent", "event": event}
+		default:
+			reply = map[string]any{"type": "error", "error": "unknown message type: " + msg.Type}
+		}
+		if err := writePanelJSON(ctx, conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func writePanelJSON(ctx context.Context, conn *websocket.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return conn.Write(ctx, websocket.MessageText, data)
+}
+
+const panelPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Deployable Sensor Panel</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.sensor { border: 1px solid #ccc; border-radius: 4px; padding: 0.6em; margin-bottom: 0.8em; }
+.sensor h2 { font-size: 1.1em; margin: 0 0 0.2em; }
+.meta { color: #666; font-size: 0.85em; margin-bottom: 0.4em; }
+.row { display: flex; flex-wrap: wrap; gap: 0.4em; align-items: center; margin-top: 0.3em; }
+button { padding: 0.5em 0.9em; }
+#status { font-size: 0.9em; margin-bottom: 1em; }
+#log { font-family: monospace; font-size: 0.8em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Sensor Panel</h1>
+<div id="status">connecting...</div>
+<button id="refresh">Refresh sensors</button>
+<div id="sensors"></div>
+<h2>Sent</h2>
+<div id="log"></div>
+<script>
+let socket;
+const statusEl = document.getElementById("status");
+const sensorsEl = document.getElementById("sensors");
+const logEl = document.getElementById("log");
+
+function connect() {
+  const scheme = location.protocol === "https:" ? "wss:" : "ws:";
+  socket = new WebSocket(scheme + "//" + location.host + "/panel/ws");
+  socket.onopen = () => { statusEl.textContent = "connected"; };
+  socket.onclose = () => { statusEl.textContent = "disconnected, retrying..."; setTimeout(connect, 2000); };
+  socket.onmessage = (msg) => {
+    const data = JSON.parse(msg.data);
+    if (data.type === "sensors") render(data.sensors);
+    if (data.type === "sent") log("sent " + data.event.sensor_id + " " + data.event.event_type + " " + JSON.stringify(data.event.value));
+    if (data.type === "error") log("error: " + data.error);
+  };
+}
+
+function log(line) {
+  logEl.textContent = new Date().toLocaleTimeString() + " " + line + "\n" + logEl.textContent;
+}
+
+function send(sensorID, eventType, value) {
+  if (!socket || socket.readyState !== WebSocket.OPEN) { log("not connected"); return; }
+  socket.send(JSON.stringify({type: "event", sensor_id: sensorID, event_type: eventType, value: value}));
+}
+
+function el(tag, props, children) {
+  const node = Object.assign(document.createElement(tag), props || {});
+  (children || []).forEach((child) => node.append(child));
+  return node;
+}
+
+function render(sensors) {
+  sensorsEl.replaceChildren();
+  if (sensors.length === 0) {
+    sensorsEl.append(el("p", {textContent: "No sensor handlers in the loaded show logic."}));
+    return;
+  }
+  sensors.forEach((sensor) => {
+    const eventTypes = sensor.event_types.length ? sensor.event_types : ["trigger"];
+    const select = el("select", {}, eventTypes.map((t) => el("option", {value: t, textContent: t})));
+    const eventType = () => select.value;
+    const buttons = el("div", {className: "row"}, [
+      el("button", {textContent: "Trigger", onclick: () => send(sensor.sensor_id, eventType(), null)}),
+    ]);
+    sensor.values.forEach((value) => {
+      buttons.append(el("button", {textContent: JSON.stringify(value), onclick: () => send(sensor.sensor_id, eventType(), value)}));
+    });
+    const numbers = sensor.values.filter((v) => typeof v === "number");
+    const max = numbers.length ? Math.max(100, ...numbers.map((n) => n * 2)) : 100;
+    const slider = el("input", {type: "range", min: 0, max: max, step: "any", value: 0});
+    const sliderValue = el("span", {textContent: "0"});
+    slider.oninput = () => { sliderValue.textContent = slider.value; };
+    slider.onchange = () => send(sensor.sensor_id, eventType(), Number(slider.value));
+    const text = el("input", {type: "text", placeholder: "value (JSON or text)"});
+    const sendText = () => {
+      let value = text.value;
+      try { value = JSON.parse(value); } catch (e) {}
+      send(sensor.sensor_id, eventType(), value);
+    };
+    text.onkeydown = (e) => { if (e.key === "Enter") sendText(); };
+    sensorsEl.append(el("div", {className: "sensor"}, [
+      el("h2", {textContent: sensor.sensor_id}),
+      el("div", {className: "meta", textContent: (sensor.sensor_type ? sensor.sensor_type + " | " : "") + "states: " + sensor.states.join(", ")}),
+      el("div", {className: "row"}, [el("label", {textContent: "event type "}), select]),
+      buttons,
+      el("div", {className: "row"}, [slider, sliderValue]),
+      el("div", {className: "row"}, [text, el("button", {textContent: "Send", onclick: sendText})]),
+    ]));
+  });
+}
+
+document.getElementById("refresh").onclick = () => {
+  if (socket && socket.readyState === WebSocket.OPEN) socket.send(JSON.stringify({type: "list"}));
+};
+connect();
+</script>
+</body>
+</html>
+`
+
@@ -2,14 +2,20 @@ package web
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"deployable/internal/runtime"
+	"deployable/internal/types"
 )
 
+const maxSensorBody = 64 << 10
+
 type Server struct {
-	Runtime *runtime.Runtime
+	Runtime     *runtime.Runtime
+	SensorPanel bool
 }
 
 func (s *Server) Listen(addr string) error {
@@ -17,6 +23,12 @@ func (s *Server) Listen(addr string) error {
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/identify", s.handleIdentify)
+	mux.HandleFunc("POST /api/sensors/{sensor_id}", s.handleSensorEvent)
+	if s.SensorPanel {
+		mux.HandleFunc("GET /panel", s.handlePanel)
+		mux.HandleFunc("GET /panel/ws", s.handlePanelSocket)
+		mux.HandleFunc("GET /api/panel/sensors", s.handlePanelSensors)
+	}
 	server := &http.Server{
 		Addr:              addr,
 		Handler:           mux,
@@ -49,3 +61,68 @@ func (s *Server) handleIdentify(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+type sensorEventRequest struct {
+	SensorType string    `json:"sensor_type"`
+	EventType  string    `json:"event_type"`
+	Value      any       `json:"value"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+func (s *Server) handleSensorEvent(w http.ResponseWriter, r *http.Request) {
+	sensorID := r.PathValue("sensor_id")
+	if !s.Runtime.AuthorizeSensor(sensorID, sensorToken(r)) {
+		writeError(w, http.StatusUnauthorized, "invalid sensor token")
+		return
+	}
+	var body sensorEventRequest
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxSensorBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "read failed")
+		return
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+			return
+		}
+	}
+	if body.EventType == "" {
+		body.EventType = "trigger"
+	}
+	if body.SensorType == "" {
+		body.SensorType = "http"
+	}
+	if body.Timestamp.IsZero() {
+		body.Timestamp = time.Now().UTC()
+	}
+	event := types.SensorEvent{
+		SensorID:   sensorID,
+		SensorType: body.SensorType,
+		EventType:  body.EventType,
+		Value:      body.Value,
+		Timestamp:  body.Timestamp,
+	}
+	s.Runtime.InjectSensorEvent(event)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(event)
+}
+
+func sensorToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if token := r.Header.Get("X-Sensor-Token"); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": message,
+	})
+}
+